@@ -3,24 +3,25 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/binary"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
-	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/cilium/ebpf/link"
-	"github.com/cilium/ebpf/perf"
 	"github.com/cilium/ebpf/rlimit"
-	"github.com/nats-io/nats.go"
+
+	"github.com/sauliuspr-reya/ebpf_agent_poc/control"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/correlation"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/encoders"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/parsers"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/probes"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/publishers"
 )
 
 // Configuration - can be overridden by environment variables
@@ -29,33 +30,48 @@ var (
 	AppID           = getEnv("APP_ID", "arbitrum-node-service")
 	TargetBinary    = getEnv("TARGET_BINARY", "/usr/local/bin/geth")
 	TargetSymbolRet = getEnv("TARGET_SYMBOL", "github.com/ethereum/go-ethereum/rpc.(*Server).serveRequest")
-	TargetPID       = getEnvInt("TARGET_PID", 0) // 0 means attach to all processes
-	DebugMode       = getEnv("DEBUG", "false") == "true"
+	Probes          = getEnv("PROBES", "tcp_send,tcp_recv") // comma-separated list, see probes.Available()
+	CorrelationTTL  = time.Duration(getEnvInt("CORRELATION_TTL_SECONDS", int(correlation.DefaultTTL/time.Second))) * time.Second
+
+	PublisherBackends = getEnv("PUBLISHER", "nats") // comma-separated: nats,mqtt,kafka,stdout
+	MQTTBroker        = getEnv("MQTT_BROKER", "tcp://localhost:1883")
+	MQTTClientID      = getEnv("MQTT_CLIENT_ID", "ebpf-agent")
+	MQTTQoS           = getEnvInt("MQTT_QOS", 0)
+	KafkaBrokers      = getEnv("KAFKA_BROKERS", "localhost:9092")
+
+	OutputFormat = getEnv("OUTPUT_FORMAT", "json") // json | senml | senml+cbor
+
+	ControlAddr = getEnv("CONTROL_ADDR", "127.0.0.1:7000") // "" disables the control server
+)
+
+// debugMode and targetPID back the DEBUG/TARGET_PID env vars. They're
+// atomics rather than plain package vars so the control server's
+// config.set can hot-reload them without a restart.
+var (
+	debugMode atomic.Bool
+	targetPID atomic.Int32
 )
 
-//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -target bpfel rpc rpc_tracer.c -- -D__TARGET_ARCH_x86 -I/usr/include/x86_64-linux-gnu
+func init() {
+	debugMode.Store(getEnv("DEBUG", "false") == "true")
+	targetPID.Store(int32(getEnvInt("TARGET_PID", 0))) // 0 means attach to all processes
+}
 
 // Agent holds the core components for the tracing service.
 type Agent struct {
-	NatsConn     *nats.Conn
+	Publisher    publishers.Publisher
+	Encoder      encoders.Encoder
 	Ctx          context.Context
 	Cancel       context.CancelFunc
-	EBPFObjs     *rpcObjects
+	ProbeMgr     atomic.Pointer[probes.ProbeManager] // set once RunTracer attaches probes; nil until then
+	Correlator   *correlation.Correlator
 	FeatureCache *sync.Map
-	PerfReader   *perf.Reader
-}
+	FeatureTail  *featureTail
+	Control      *control.Server
 
-// RPCEvent represents data sent from the BPF program to the Go User-Space Agent.
-// Must match the C struct (network_event_t) defined in rpc_tracer.c exactly.
-type RPCEvent struct {
-	PID         uint64
-	TimestampNs uint64
-	DataLen     uint32
-	IsSend      uint32 // 1 = send (tcp_sendmsg), 0 = recv (tcp_recvmsg)
-	DestIP      uint32 // Destination IPv4 address
-	DestPort    uint16 // Destination port
-	Comm        [16]byte
-	Data        [512]byte // HTTP headers + JSON-RPC payload
+	StartTime       time.Time
+	EventsProcessed uint64 // atomic
+	EventsDropped   uint64 // atomic
 }
 
 // MonitoringFeature is the standard structure published to NATS.
@@ -88,37 +104,71 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
-// connectNATS establishes a connection to the NATS server with retry logic.
-func connectNATS(url string) (*nats.Conn, error) {
-	log.Printf("Connecting to NATS at %s...", url)
-	for i := 0; i < 5; i++ {
-		nc, err := nats.Connect(url, nats.Timeout(5*time.Second))
-		if err == nil {
-			log.Println("Successfully connected to NATS.")
-			return nc, nil
+// newPublisherFromEnv constructs the Publisher(s) selected by the
+// PUBLISHER env var (comma-separated, e.g. "nats,kafka" to mirror to
+// both). A single backend is returned as-is; more than one is wrapped in
+// a MultiPublisher.
+func newPublisherFromEnv() (publishers.Publisher, error) {
+	var backends []publishers.Publisher
+	for _, name := range strings.Split(PublisherBackends, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "", "nats":
+			p, err := publishers.NewNATSPublisher(NatsURL)
+			if err != nil {
+				return nil, err
+			}
+			backends = append(backends, p)
+		case "mqtt":
+			p, err := publishers.NewMQTTPublisher(MQTTBroker, MQTTClientID, byte(MQTTQoS))
+			if err != nil {
+				return nil, err
+			}
+			backends = append(backends, p)
+		case "kafka":
+			backends = append(backends, publishers.NewKafkaPublisher(strings.Split(KafkaBrokers, ",")))
+		case "stdout":
+			backends = append(backends, publishers.NewStdoutPublisher())
+		default:
+			return nil, fmt.Errorf("unknown PUBLISHER backend %q", name)
 		}
-		log.Printf("NATS connection attempt %d failed: %v. Retrying in %d seconds...", i+1, err, 1<<i)
-		time.Sleep(time.Duration(1<<i) * time.Second)
 	}
-	return nil, fmt.Errorf("failed to connect to NATS after multiple retries")
+
+	if len(backends) == 1 {
+		return backends[0], nil
+	}
+	return publishers.NewMultiPublisher(backends...), nil
 }
 
-// PublishFeature sends a MonitoringFeature to NATS on the appropriate topic
+// PublishFeature sends a MonitoringFeature to the configured Publisher
+// backend(s) on the appropriate subject/topic.
 func (a *Agent) PublishFeature(feature MonitoringFeature) error {
 	// Construct the NATS subject with hierarchical structure
 	// Format: rpc.{destination}.{protocol}.{method}.{metric}
 	subject := feature.ContextHash // This now contains the full subject
 
-	data, err := json.Marshal(feature)
+	data, contentType, err := a.Encoder.Encode(encoders.Feature{
+		AppID:       feature.AppID,
+		Protocol:    feature.Protocol,
+		FeatureType: feature.FeatureType,
+		Timestamp:   feature.Timestamp,
+		Value:       feature.Value,
+		ContextHash: feature.ContextHash,
+		Details:     feature.Details,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal feature: %w", err)
+		return fmt.Errorf("failed to encode feature: %w", err)
 	}
 
-	if err := a.NatsConn.Publish(subject, data); err != nil {
+	if err := a.Publisher.Publish(subject, data, contentType); err != nil {
 		return fmt.Errorf("failed to publish to subject %s: %w", subject, err)
 	}
 
-	log.Printf("Published to NATS [%s]: %s", subject, string(data))
+	if a.FeatureTail != nil {
+		a.FeatureTail.record(feature)
+	}
+
+	log.Printf("Published [%s] (%s): %s", subject, contentType, string(data))
 	return nil
 }
 
@@ -141,72 +191,62 @@ func getHostnameFromIP(ipStr string) string {
 		hostname = strings.ReplaceAll(hostname, ".", "-")
 		return hostname
 	}
-	
+
 	// If DNS fails, use IP with hyphens
 	return strings.ReplaceAll(ipStr, ".", "-")
 }
 
-// extractETHMethodFromPayload attempts to extract eth_* method from HTTP/JSON-RPC payload
-func extractETHMethodFromPayload(payload string) string {
-	// Look for JSON-RPC method in payload
-	// Patterns: {"method":"eth_call",...} or {"jsonrpc":"2.0","method":"eth_getBalance",...}
-	
-	re := regexp.MustCompile(`"method"\s*:\s*"(eth_[a-zA-Z0-9_]+)"`)
-	matches := re.FindStringSubmatch(payload)
-	if len(matches) > 1 {
-		return matches[1]
-	}
-	
-	// Also check for HTTP POST path (some RPC endpoints use path-based routing)
-	if strings.Contains(payload, "POST /") {
-		// Look for common patterns
-		if strings.Contains(payload, "eth_call") {
-			return "eth_call"
+// enabledProbes parses the Probes env var into the deduplicated set of
+// probes.Probe instances it names, in the order they first appear.
+func enabledProbes() ([]probes.Probe, error) {
+	seen := make(map[string]bool)
+	var enabled []probes.Probe
+	for _, name := range strings.Split(Probes, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
 		}
-		if strings.Contains(payload, "eth_sendTransaction") {
-			return "eth_sendTransaction"
+		p, err := probes.New(name)
+		if err != nil {
+			return nil, err
 		}
-		if strings.Contains(payload, "eth_getBalance") {
-			return "eth_getBalance"
+		if seen[p.Name()] {
+			continue
 		}
+		seen[p.Name()] = true
+		enabled = append(enabled, p)
 	}
-	
-	return "unknown"
+	return enabled, nil
 }
 
-// RunTracer initializes eBPF, attaches the probes, and starts the event loop.
+// RunTracer initializes eBPF, attaches the configured probes, and starts
+// the event loop.
 func (a *Agent) RunTracer() error {
 	// Allow the BPF programs to be loaded (required for Kubernetes/restricted environments)
 	if err := rlimit.RemoveMemlock(); err != nil {
 		return fmt.Errorf("failed to remove memlock limit: %w", err)
 	}
 
-	// Load pre-compiled eBPF programs
-	objs := &rpcObjects{}
-	if err := loadRpcObjects(objs, nil); err != nil {
-		return fmt.Errorf("failed to load eBPF objects: %w", err)
+	enabled, err := enabledProbes()
+	if err != nil {
+		return fmt.Errorf("failed to resolve PROBES=%q: %w", Probes, err)
 	}
-	a.EBPFObjs = objs
-
-	log.Println("Attaching Kprobe to tcp_sendmsg...")
 
-	// Attach Kprobe to tcp_sendmsg (kernel function for sending TCP data)
-	kp, err := link.Kprobe("tcp_sendmsg", a.EBPFObjs.TraceTcpSendmsg, nil)
-	if err != nil {
-		return fmt.Errorf("failed to attach Kprobe to tcp_sendmsg: %w", err)
+	mgr := probes.NewProbeManager()
+	for _, p := range enabled {
+		mgr.Add(p)
 	}
-	defer kp.Close()
-	log.Println("Kprobe attached successfully to tcp_sendmsg")
+	a.ProbeMgr.Store(mgr)
 
-	// Start reading from the Perf Buffer
-	rd, err := perf.NewReader(a.EBPFObjs.Events, os.Getpagesize()*64)
-	if err != nil {
-		return fmt.Errorf("failed to create perf event reader: %w", err)
+	log.Printf("Attaching probes: %s", Probes)
+	if err := mgr.Start(a.Ctx); err != nil {
+		return fmt.Errorf("failed to start probes: %w", err)
 	}
-	a.PerfReader = rd
+	defer mgr.Close()
+	log.Println("Probes attached successfully.")
 
-	log.Println("Starting Perf Buffer reader...")
-	if DebugMode {
+	log.Println("Starting event loop...")
+	if debugMode.Load() {
 		log.Println("DEBUG: Debug mode enabled - verbose logging active")
 	}
 	go a.readAndProcessEvents()
@@ -216,84 +256,214 @@ func (a *Agent) RunTracer() error {
 	return nil
 }
 
-// readAndProcessEvents continuously reads raw events from the kernel and processes them.
+// readAndProcessEvents continuously reads decoded events fanned in from
+// every attached probe and routes each to the feature-engineering logic
+// for its kind.
 func (a *Agent) readAndProcessEvents() {
-	var event RPCEvent
 	eventCount := 0
 
-	for {
-		record, err := a.PerfReader.Read()
-		if err != nil {
-			if errors.Is(err, perf.ErrClosed) {
-				log.Println("Perf buffer reader closed.")
-				return
+	for ev := range a.ProbeMgr.Load().Events() {
+		switch data := ev.Data.(type) {
+		case error:
+			atomic.AddUint64(&a.EventsDropped, 1)
+			log.Printf("Probe %q error: %v", ev.Probe, data)
+		case probes.TCPEvent:
+			eventCount++
+			atomic.AddUint64(&a.EventsProcessed, 1)
+			if debugMode.Load() {
+				log.Printf("DEBUG: Received event #%d from %q: PID=%d, DataLen=%d, IsSend=%d, Comm=%s",
+					eventCount, ev.Probe, data.PID, data.DataLen, data.IsSend,
+					string(bytes.TrimRight(data.Comm[:], "\x00")))
 			}
-			log.Printf("Error reading perf buffer: %v", err)
-			continue
-		}
-
-		if record.LostSamples > 0 {
-			log.Printf("Warning: Lost %d samples due to a full buffer", record.LostSamples)
-		}
-
-		// Parse binary data into our Go struct
-		if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.LittleEndian, &event); err != nil {
-			log.Printf("Failed to parse event: %v", err)
-			continue
-		}
-
-		eventCount++
-		if DebugMode {
-			log.Printf("DEBUG: Received event #%d: PID=%d, DataLen=%d, IsSend=%d, Comm=%s",
-				eventCount, event.PID, event.DataLen, event.IsSend, 
-				string(bytes.TrimRight(event.Comm[:], "\x00")))
+			a.processAndPublishRPCEvent(data)
+		case probes.RetransmitEvent:
+			atomic.AddUint64(&a.EventsProcessed, 1)
+			a.publishRetransmitFeature(data)
+		case probes.ResetEvent:
+			atomic.AddUint64(&a.EventsProcessed, 1)
+			a.publishResetFeature(data)
+		case probes.LatencyEvent:
+			atomic.AddUint64(&a.EventsProcessed, 1)
+			a.publishLatencyFeature(data)
+		default:
+			log.Printf("Probe %q produced an event of unexpected type %T", ev.Probe, data)
 		}
-
-		// Feature Engineering and Publishing
-		a.processAndPublishRPCEvent(event)
 	}
 }
 
-// processAndPublishRPCEvent performs feature extraction and sends the feature over NATS.
-func (a *Agent) processAndPublishRPCEvent(event RPCEvent) {
+// processAndPublishRPCEvent dispatches the raw payload through the parser
+// registry and publishes one MonitoringFeature per ParsedEvent it yields
+// (a JSON-RPC batch, for instance, produces several). If no registered
+// parser claims the payload, a single feature with method "unknown" is
+// published so size/latency visibility isn't lost for unrecognized
+// protocols.
+func (a *Agent) processAndPublishRPCEvent(event probes.TCPEvent) {
 	processName := string(bytes.TrimRight(event.Comm[:], "\x00"))
-	
-	// Convert destination IP to string
 	destIPStr := ipToString(event.DestIP)
 	destHostname := getHostnameFromIP(destIPStr)
-	
-	// Determine direction and metric type
+
 	direction := "recv"
 	metricType := "response"
-	if event.IsSend == 1 {
+	isReq := event.IsSend == 1
+	if isReq {
 		direction = "send"
 		metricType = "request"
 	}
-	
-	// Try to extract ETH JSON-RPC method from payload
-	payload := string(bytes.TrimRight(event.Data[:], "\x00"))
-	ethMethod := extractETHMethodFromPayload(payload)
-	
-	// Construct hierarchical NATS subject
-	// Format: rpc.{destination}.{protocol}.{method}.{metric}
-	// Example: rpc.rpc-reya-cronos-gelato-digital.https.eth_call.request_size
+
+	payload := bytes.TrimRight(event.Data[:], "\x00")
+	sessionKey := fmt.Sprintf("%d:%s:%d", event.PID, destIPStr, event.DestPort)
+
+	parsedEvents := []parsers.ParsedEvent{{Method: "unknown"}}
+	if parser := parsers.Detect(payload, event.DestPort); parser != nil {
+		if events, err := parser.Parse(sessionKey, isReq, payload); err != nil {
+			if debugMode.Load() {
+				log.Printf("DEBUG: parser %q failed on session %s: %v", parser.Name(), sessionKey, err)
+			}
+		} else if len(events) > 0 {
+			parsedEvents = events
+		}
+	}
+
+	if debugMode.Load() {
+		log.Printf("DEBUG: Processing %s to %s:%d (PID %d): size=%d",
+			direction, destIPStr, event.DestPort, event.PID, event.DataLen)
+		if len(payload) > 0 && len(payload) < 200 {
+			log.Printf("DEBUG: Payload preview: %s", string(payload))
+		}
+	}
+
+	for _, parsed := range parsedEvents {
+		a.publishParsedFeature(event, parsed, processName, destIPStr, destHostname, direction, metricType)
+		a.correlateParsedEvent(event, parsed, sessionKey, isReq, destHostname)
+	}
+}
+
+// correlateParsedEvent feeds one ParsedEvent into the request/response
+// correlator: the send side is tracked, the recv side is matched against
+// it, and a match publishes an rpc_latency_ms feature. Orphaned requests
+// are reported separately, via publishTimeoutFeature, when the
+// correlator's LRU evicts them.
+func (a *Agent) correlateParsedEvent(event probes.TCPEvent, parsed parsers.ParsedEvent, sessionKey string, isReq bool, destHostname string) {
+	if a.Correlator == nil {
+		return
+	}
+
+	jsonrpcID := parsed.Tags["jsonrpc_id"]
+
+	if isReq {
+		a.Correlator.TrackRequest(sessionKey, destHostname, jsonrpcID, parsed.Method, correlation.HashParams(parsed.Params), event.DataLen, event.TimestampNs)
+		return
+	}
+
+	result, ok := a.Correlator.MatchResponse(sessionKey, jsonrpcID, event.DataLen, event.TimestampNs, parsed.StatusCode)
+	if !ok {
+		return
+	}
+	a.publishLatencyResult(result, destHostname)
+}
+
+// publishLatencyResult publishes the rpc_latency_ms feature for a
+// matched request/response pair.
+func (a *Agent) publishLatencyResult(result correlation.Result, destHostname string) {
+	subject := fmt.Sprintf("rpc.%s.jsonrpc.%s.rpc_latency_ms", destHostname, result.Method)
+	feature := MonitoringFeature{
+		AppID:       AppID,
+		Protocol:    "jsonrpc",
+		FeatureType: "rpc_latency_ms",
+		Timestamp:   time.Now(),
+		Value:       result.LatencyMs,
+		ContextHash: subject,
+		Details: map[string]interface{}{
+			"method":          result.Method,
+			"params_hash":     result.ParamsHash,
+			"response_status": result.ResponseStatus,
+			"bytes_out":       result.BytesOut,
+			"bytes_in":        result.BytesIn,
+			"dest_hostname":   destHostname,
+		},
+	}
+	if err := a.PublishFeature(feature); err != nil {
+		log.Printf("Failed to publish rpc_latency_ms feature: %v", err)
+	}
+}
+
+// publishTimeoutFeature publishes an rpc_timeout feature for a request
+// the correlator evicted without ever seeing a response. It is invoked
+// from the correlator's own timeout-draining goroutine (not the LRU's
+// janitor), so a slow publish only delays later timeout reports.
+func (a *Agent) publishTimeoutFeature(result correlation.Result) {
+	destHostname := result.DestHostname
+	if destHostname == "" {
+		destHostname = "unknown"
+	}
+	subject := fmt.Sprintf("rpc.%s.jsonrpc.%s.rpc_timeout", destHostname, result.Method)
+	feature := MonitoringFeature{
+		AppID:       AppID,
+		Protocol:    "jsonrpc",
+		FeatureType: "rpc_timeout",
+		Timestamp:   time.Now(),
+		Value:       1,
+		ContextHash: subject,
+		Details: map[string]interface{}{
+			"method":      result.Method,
+			"params_hash": result.ParamsHash,
+			"bytes_out":   result.BytesOut,
+			"session_key": result.SessionKey,
+		},
+	}
+	if err := a.PublishFeature(feature); err != nil {
+		log.Printf("Failed to publish rpc_timeout feature: %v", err)
+	}
+}
+
+// publishParsedFeature builds a MonitoringFeature for one ParsedEvent and
+// publishes it through the configured Publisher under the usual
+// rpc.{destination}.{protocol}.{method}.{metric} subject.
+func (a *Agent) publishParsedFeature(event probes.TCPEvent, parsed parsers.ParsedEvent, processName, destIPStr, destHostname, direction, metricType string) {
+	method := parsed.Method
+	if method == "" {
+		method = "unknown"
+	}
+
 	protocol := "https"
 	if event.DestPort == 8545 || event.DestPort == 8547 {
 		protocol = "http"
 	}
-	
-	subject := fmt.Sprintf("rpc.%s.%s.%s.%s_size", 
-		destHostname, protocol, ethMethod, metricType)
-	
-	if DebugMode {
-		log.Printf("DEBUG: Processing %s to %s:%d (PID %d): method=%s, size=%d",
-			direction, destIPStr, event.DestPort, event.PID, ethMethod, event.DataLen)
-		if len(payload) > 0 && len(payload) < 200 {
-			log.Printf("DEBUG: Payload preview: %s", payload)
-		}
+
+	details := map[string]interface{}{
+		"pid":           event.PID,
+		"process":       processName,
+		"method":        method,
+		"direction":     direction,
+		"size_bytes":    event.DataLen,
+		"timestamp_ns":  event.TimestampNs,
+		"dest_ip":       destIPStr,
+		"dest_port":     event.DestPort,
+		"dest_hostname": destHostname,
+	}
+	if parsed.StatusCode != 0 {
+		details["status_code"] = parsed.StatusCode
+	}
+	for k, v := range parsed.Tags {
+		details[k] = v
+	}
+	for k, v := range parsed.Details {
+		details[k] = v
+	}
+
+	// When the HTTP parser found a real upstream client IP in the
+	// forwarding headers (the on-the-wire DestIP is often just the
+	// local reverse proxy), prefer its hostname in the subject.
+	subjectHostname := destHostname
+	if upstreamIP, ok := details["upstream_ip"].(string); ok {
+		upstreamHostname := getHostnameFromIP(upstreamIP)
+		details["upstream_hostname"] = upstreamHostname
+		subjectHostname = upstreamHostname
 	}
 
-	// Create monitoring feature
+	subject := fmt.Sprintf("rpc.%s.%s.%s.%s_size",
+		subjectHostname, protocol, method, metricType)
+
 	feature := MonitoringFeature{
 		AppID:       AppID,
 		Protocol:    "jsonrpc",
@@ -301,25 +471,99 @@ func (a *Agent) processAndPublishRPCEvent(event RPCEvent) {
 		Timestamp:   time.Now(),
 		Value:       float64(event.DataLen),
 		ContextHash: subject, // Full subject path
+		Details:     details,
+	}
+
+	if err := a.PublishFeature(feature); err != nil {
+		log.Printf("Failed to publish RPC feature: %v", err)
+	} else if debugMode.Load() {
+		log.Printf("DEBUG: Published to NATS [%s]: method=%s, size=%d",
+			subject, method, event.DataLen)
+	}
+}
+
+// publishRetransmitFeature reports a single TCP retransmission as a
+// `retransmit` feature, one per occurrence rather than aggregated, so
+// downstream consumers can apply their own windowing.
+func (a *Agent) publishRetransmitFeature(event probes.RetransmitEvent) {
+	destIPStr := ipToString(event.DestIP)
+	destHostname := getHostnameFromIP(destIPStr)
+	processName := string(bytes.TrimRight(event.Comm[:], "\x00"))
+
+	subject := fmt.Sprintf("rpc.%s.tcp.retransmit", destHostname)
+	feature := MonitoringFeature{
+		AppID:       AppID,
+		Protocol:    "tcp",
+		FeatureType: "retransmit",
+		Timestamp:   time.Now(),
+		Value:       1,
+		ContextHash: subject,
+		Details: map[string]interface{}{
+			"pid":           event.PID,
+			"process":       processName,
+			"dest_ip":       destIPStr,
+			"dest_port":     event.DestPort,
+			"dest_hostname": destHostname,
+		},
+	}
+	if err := a.PublishFeature(feature); err != nil {
+		log.Printf("Failed to publish retransmit feature: %v", err)
+	}
+}
+
+// publishResetFeature reports a TCP RST sent on a traced connection as a
+// `tcp_reset` feature.
+func (a *Agent) publishResetFeature(event probes.ResetEvent) {
+	destIPStr := ipToString(event.DestIP)
+	destHostname := getHostnameFromIP(destIPStr)
+	processName := string(bytes.TrimRight(event.Comm[:], "\x00"))
+
+	subject := fmt.Sprintf("rpc.%s.tcp.tcp_reset", destHostname)
+	feature := MonitoringFeature{
+		AppID:       AppID,
+		Protocol:    "tcp",
+		FeatureType: "tcp_reset",
+		Timestamp:   time.Now(),
+		Value:       1,
+		ContextHash: subject,
 		Details: map[string]interface{}{
 			"pid":           event.PID,
 			"process":       processName,
-			"method":        ethMethod,
-			"direction":     direction,
-			"size_bytes":    event.DataLen,
-			"timestamp_ns":  event.TimestampNs,
 			"dest_ip":       destIPStr,
 			"dest_port":     event.DestPort,
 			"dest_hostname": destHostname,
 		},
 	}
+	if err := a.PublishFeature(feature); err != nil {
+		log.Printf("Failed to publish tcp_reset feature: %v", err)
+	}
+}
+
+// publishLatencyFeature reports the tcp_connect-to-first-tcp_sendmsg
+// delay for a connection as a `socket_latency_ms` feature.
+func (a *Agent) publishLatencyFeature(event probes.LatencyEvent) {
+	destIPStr := ipToString(event.DestIP)
+	destHostname := getHostnameFromIP(destIPStr)
+	processName := string(bytes.TrimRight(event.Comm[:], "\x00"))
 
-	// Publish to NATS
+	subject := fmt.Sprintf("rpc.%s.tcp.socket_latency_ms", destHostname)
+	feature := MonitoringFeature{
+		AppID:       AppID,
+		Protocol:    "tcp",
+		FeatureType: "socket_latency_ms",
+		Timestamp:   time.Now(),
+		Value:       float64(event.LatencyDeltaNs) / float64(time.Millisecond),
+		ContextHash: subject,
+		Details: map[string]interface{}{
+			"pid":           event.PID,
+			"process":       processName,
+			"dest_ip":       destIPStr,
+			"dest_port":     event.DestPort,
+			"dest_hostname": destHostname,
+		},
+	}
 	if err := a.PublishFeature(feature); err != nil {
-		log.Printf("Failed to publish RPC feature: %v", err)
-	} else if DebugMode {
-		log.Printf("DEBUG: Published to NATS [%s]: method=%s, size=%d",
-			subject, ethMethod, event.DataLen)
+		log.Printf("Failed to publish socket_latency feature: %v", err)
 	}
 }
 
@@ -338,7 +582,7 @@ func extractJSONRPCMethod(payload string) string {
 	if len(payload) == 0 {
 		return "unknown"
 	}
-	
+
 	// Look for "method":"
 	methodStart := bytes.Index([]byte(payload), []byte(`"method":"`))
 	if methodStart == -1 {
@@ -347,18 +591,18 @@ func extractJSONRPCMethod(payload string) string {
 	if methodStart == -1 {
 		return "unknown"
 	}
-	
+
 	// Find the start of the method value
 	valueStart := methodStart + bytes.Index([]byte(payload[methodStart:]), []byte(`"`))
 	valueStart = valueStart + bytes.Index([]byte(payload[valueStart+1:]), []byte(`"`)) + 1
-	
+
 	// Find the end quote
 	valueEnd := valueStart + 1 + bytes.Index([]byte(payload[valueStart+1:]), []byte(`"`))
-	
+
 	if valueEnd > valueStart && valueEnd < len(payload) {
 		return payload[valueStart+1 : valueEnd]
 	}
-	
+
 	return "unknown"
 }
 
@@ -369,7 +613,12 @@ func main() {
 	log.Printf("  App ID: %s", AppID)
 	log.Printf("  Target Binary: %s", TargetBinary)
 	log.Printf("  Target Symbol: %s", TargetSymbolRet)
-	log.Printf("  Target PID: %d (0 = all processes)", TargetPID)
+	log.Printf("  Target PID: %d (0 = all processes)", targetPID.Load())
+	log.Printf("  Probes: %s", Probes)
+	log.Printf("  Correlation TTL: %s", CorrelationTTL)
+	log.Printf("  Publisher: %s", PublisherBackends)
+	log.Printf("  Output format: %s", OutputFormat)
+	log.Printf("  Control addr: %s", ControlAddr)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -383,31 +632,42 @@ func main() {
 		cancel()
 	}()
 
-	// 1. Connect to NATS
-	nc, err := connectNATS(NatsURL)
+	// 1. Connect to the configured Publisher backend(s)
+	publisher, err := newPublisherFromEnv()
+	if err != nil {
+		log.Fatalf("Fatal: %v", err)
+	}
+	defer publisher.Close()
+
+	encoder, err := encoders.New(OutputFormat)
 	if err != nil {
 		log.Fatalf("Fatal: %v", err)
 	}
-	defer nc.Close()
 
 	agent := &Agent{
-		NatsConn:     nc,
+		Publisher:    publisher,
+		Encoder:      encoder,
 		Ctx:          ctx,
 		Cancel:       cancel,
 		FeatureCache: &sync.Map{},
+		FeatureTail:  newFeatureTail(),
+		StartTime:    time.Now(),
 	}
+	agent.Correlator = correlation.New(CorrelationTTL, agent.publishTimeoutFeature)
 
-	// 2. Start the eBPF Tracer
-	if err := agent.RunTracer(); err != nil {
-		log.Fatalf("Fatal: Failed to run eBPF tracer: %v", err)
+	// Start the embedded JSON-RPC control/query server, unless disabled.
+	if ControlAddr != "" {
+		agent.Control = agent.newControlServer()
+		if err := agent.Control.Start(); err != nil {
+			log.Fatalf("Fatal: Failed to start control server: %v", err)
+		}
+		defer agent.Control.Close()
+		log.Printf("Control server listening on %s", ControlAddr)
 	}
 
-	// Clean up resources
-	if agent.PerfReader != nil {
-		agent.PerfReader.Close()
-	}
-	if agent.EBPFObjs != nil {
-		agent.EBPFObjs.Close()
+	// 2. Start the eBPF Tracer (closes its probes on return)
+	if err := agent.RunTracer(); err != nil {
+		log.Fatalf("Fatal: Failed to run eBPF tracer: %v", err)
 	}
 
 	log.Println("Agent stopped gracefully.")
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sauliuspr-reya/ebpf_agent_poc/control"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/correlation"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/parsers"
+	"github.com/sauliuspr-reya/ebpf_agent_poc/probes"
+)
+
+// featureTailCapacity bounds how many recently published features
+// features.tail can replay to a new subscriber before switching to
+// live updates.
+const featureTailCapacity = 256
+
+// featureTail buffers recently published features and fans them out to
+// any live features.tail subscribers, so a feature published just
+// before a client subscribes isn't missed.
+type featureTail struct {
+	mu   sync.Mutex
+	buf  []MonitoringFeature
+	subs map[chan MonitoringFeature]struct{}
+}
+
+func newFeatureTail() *featureTail {
+	return &featureTail{subs: make(map[chan MonitoringFeature]struct{})}
+}
+
+func (t *featureTail) record(f MonitoringFeature) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, f)
+	if len(t.buf) > featureTailCapacity {
+		t.buf = t.buf[len(t.buf)-featureTailCapacity:]
+	}
+	for ch := range t.subs {
+		select {
+		case ch <- f:
+		default: // subscriber falling behind; drop rather than block publishing
+		}
+	}
+}
+
+// lastLocked returns (a copy of) the most recent n buffered features,
+// oldest first, and must be called with t.mu held. n <= 0 or greater
+// than the buffered count returns everything buffered.
+func (t *featureTail) lastLocked(n int) []MonitoringFeature {
+	if n <= 0 || n > len(t.buf) {
+		n = len(t.buf)
+	}
+	out := make([]MonitoringFeature, n)
+	copy(out, t.buf[len(t.buf)-n:])
+	return out
+}
+
+// subscribeWithBacklog atomically subscribes a new channel and snapshots
+// the last n buffered features, so a feature published between the two
+// steps can't land in both the snapshot and the channel (which would
+// otherwise deliver it to the caller twice: once via the replayed
+// backlog, once via the live channel).
+func (t *featureTail) subscribeWithBacklog(n int) (chan MonitoringFeature, []MonitoringFeature) {
+	ch := make(chan MonitoringFeature, 64)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.subs[ch] = struct{}{}
+	return ch, t.lastLocked(n)
+}
+
+func (t *featureTail) unsubscribe(ch chan MonitoringFeature) {
+	t.mu.Lock()
+	delete(t.subs, ch)
+	t.mu.Unlock()
+}
+
+// newControlServer builds the control.Server wired to this Agent's state
+// and registers every method requests.jsonl asks for. It does not start
+// listening; call Start on the result.
+func (a *Agent) newControlServer() *control.Server {
+	s := control.NewServer(ControlAddr)
+
+	s.Handle("agent.status", a.handleAgentStatus)
+	s.Handle("probes.list", a.handleProbesList)
+	s.Handle("probes.enable", a.handleProbesEnable)
+	s.Handle("probes.disable", a.handleProbesDisable)
+	s.Handle("parsers.list", a.handleParsersList)
+	s.Handle("config.get", a.handleConfigGet)
+	s.Handle("config.set", a.handleConfigSet)
+	s.Handle("metrics.snapshot", a.handleMetricsSnapshot)
+	s.HandleStream("features.tail", a.handleFeaturesTail)
+
+	return s
+}
+
+func (a *Agent) handleAgentStatus(_ json.RawMessage) (interface{}, error) {
+	var names []string
+	if mgr := a.ProbeMgr.Load(); mgr != nil {
+		names = mgr.Names()
+	}
+	return map[string]interface{}{
+		"uptime_seconds":   time.Since(a.StartTime).Seconds(),
+		"events_processed": atomic.LoadUint64(&a.EventsProcessed),
+		"events_dropped":   atomic.LoadUint64(&a.EventsDropped),
+		"probes":           names,
+	}, nil
+}
+
+func (a *Agent) handleProbesList(_ json.RawMessage) (interface{}, error) {
+	running := make(map[string]bool)
+	if mgr := a.ProbeMgr.Load(); mgr != nil {
+		for _, name := range mgr.Names() {
+			running[name] = true
+		}
+	}
+	available := probes.Available()
+	out := make([]map[string]interface{}, 0, len(available))
+	for _, name := range available {
+		out = append(out, map[string]interface{}{
+			"name":    name,
+			"enabled": running[name],
+		})
+	}
+	return out, nil
+}
+
+type probeNameParams struct {
+	Name string `json:"name"`
+}
+
+func (a *Agent) handleProbesEnable(params json.RawMessage) (interface{}, error) {
+	var p probeNameParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	mgr := a.ProbeMgr.Load()
+	if mgr == nil {
+		return nil, fmt.Errorf("probes: agent is not tracing yet")
+	}
+	probe, err := probes.New(p.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Enable(a.Ctx, probe); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "enabled"}, nil
+}
+
+func (a *Agent) handleProbesDisable(params json.RawMessage) (interface{}, error) {
+	var p probeNameParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	mgr := a.ProbeMgr.Load()
+	if mgr == nil {
+		return nil, fmt.Errorf("probes: agent is not tracing yet")
+	}
+	if err := mgr.Disable(p.Name); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "disabled"}, nil
+}
+
+func (a *Agent) handleParsersList(_ json.RawMessage) (interface{}, error) {
+	registered := parsers.Registered()
+	names := make([]string, 0, len(registered))
+	for _, p := range registered {
+		names = append(names, p.Name())
+	}
+	return names, nil
+}
+
+func (a *Agent) handleConfigGet(_ json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"debug":      debugMode.Load(),
+		"target_pid": int(targetPID.Load()),
+	}, nil
+}
+
+type configSetParams struct {
+	Debug     *bool `json:"debug"`
+	TargetPID *int  `json:"target_pid"`
+}
+
+func (a *Agent) handleConfigSet(params json.RawMessage) (interface{}, error) {
+	var p configSetParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if p.Debug != nil {
+		debugMode.Store(*p.Debug)
+	}
+	if p.TargetPID != nil {
+		targetPID.Store(int32(*p.TargetPID))
+	}
+	return a.handleConfigGet(nil)
+}
+
+func (a *Agent) handleMetricsSnapshot(_ json.RawMessage) (interface{}, error) {
+	if a.Correlator == nil {
+		return map[string]correlation.MethodMetrics{}, nil
+	}
+	return a.Correlator.Snapshot(), nil
+}
+
+type featuresTailParams struct {
+	Count int `json:"count"`
+}
+
+// handleFeaturesTail replies with a subscription id, replays up to Count
+// recently published features as features.update notifications, then
+// keeps notifying as new features publish until the client disconnects.
+func (a *Agent) handleFeaturesTail(params json.RawMessage, respond func(interface{}) error, notify func(string, interface{}) error, done <-chan struct{}) error {
+	var p featuresTailParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if p.Count <= 0 {
+		p.Count = 20
+	}
+
+	ch, backlog := a.FeatureTail.subscribeWithBacklog(p.Count)
+	defer a.FeatureTail.unsubscribe(ch)
+
+	if err := respond(map[string]string{"subscription": fmt.Sprintf("tail-%d", time.Now().UnixNano())}); err != nil {
+		return err
+	}
+
+	for _, f := range backlog {
+		if err := notify("features.update", f); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case f := <-ch:
+			if err := notify("features.update", f); err != nil {
+				return err
+			}
+		}
+	}
+}
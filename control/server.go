@@ -0,0 +1,190 @@
+// Package control implements the agent's embedded JSON-RPC 2.0
+// control/query server: a small net/http-based dispatcher (no framework)
+// that lets an operator introspect and reconfigure a running agent
+// instead of relying on env vars and log scraping. The server itself
+// knows nothing about probes, parsers, or features; callers register
+// method handlers that close over whatever agent state they need.
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Request is a JSON-RPC 2.0 request object.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC 2.0 response or notification object. A response
+// carries ID and exactly one of Result/Error; a notification carries
+// Method and Params instead of ID.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC 2.0 error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// HandlerFunc answers a single request/response method call.
+type HandlerFunc func(params json.RawMessage) (interface{}, error)
+
+// StreamHandlerFunc answers a method call that keeps the HTTP connection
+// open after its initial response and pushes further JSON-RPC
+// notifications over it via notify. respond must be called exactly once,
+// before any call to notify. The handler should keep running until done
+// is closed (the client disconnected or the server is shutting down).
+type StreamHandlerFunc func(params json.RawMessage, respond func(result interface{}) error, notify func(method string, params interface{}) error, done <-chan struct{}) error
+
+// Server is a minimal JSON-RPC 2.0 dispatcher served over net/http: one
+// HTTP endpoint decodes a request, looks up its method by name, and
+// writes back the result (or error) as a single JSON object, optionally
+// followed by a stream of notifications on the same connection.
+type Server struct {
+	addr string
+
+	mu      sync.RWMutex
+	methods map[string]HandlerFunc
+	streams map[string]StreamHandlerFunc
+
+	httpServer *http.Server
+}
+
+// NewServer creates a Server bound to addr. It does not start listening
+// until Start is called.
+func NewServer(addr string) *Server {
+	return &Server{
+		addr:    addr,
+		methods: make(map[string]HandlerFunc),
+		streams: make(map[string]StreamHandlerFunc),
+	}
+}
+
+// Handle registers a request/response method.
+func (s *Server) Handle(method string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[method] = fn
+}
+
+// HandleStream registers a method whose handler keeps pushing
+// notifications after its initial response.
+func (s *Server) HandleStream(method string, fn StreamHandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[method] = fn
+}
+
+// Start binds the server's listener and begins serving in the
+// background. Returning after a successful bind (rather than blocking
+// for the server's lifetime) matches how the agent's other backends
+// (NATS, MQTT, Kafka) report connection failures synchronously from
+// their constructors.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("control: listening on %s: %w", s.addr, err)
+	}
+
+	s.httpServer = &http.Server{Handler: s}
+	go func() {
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("control: server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Close shuts down the HTTP server, closing any open connections
+// (including in-progress features.tail streams).
+func (s *Server) Close() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Close()
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "control: only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, nil, nil, &Error{Code: -32700, Message: "parse error: " + err.Error()})
+		return
+	}
+
+	s.mu.RLock()
+	stream, isStream := s.streams[req.Method]
+	handler, isMethod := s.methods[req.Method]
+	s.mu.RUnlock()
+
+	switch {
+	case isStream:
+		s.serveStream(w, r, req, stream)
+	case isMethod:
+		result, err := handler(req.Params)
+		if err != nil {
+			writeResponse(w, req.ID, nil, &Error{Code: -32000, Message: err.Error()})
+			return
+		}
+		writeResponse(w, req.ID, result, nil)
+	default:
+		writeResponse(w, req.ID, nil, &Error{Code: -32601, Message: fmt.Sprintf("method not found: %s", req.Method)})
+	}
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request, req Request, fn StreamHandlerFunc) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeResponse(w, req.ID, nil, &Error{Code: -32000, Message: "control: streaming not supported by this connection"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	write := func(resp Response) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	respond := func(result interface{}) error {
+		return write(Response{JSONRPC: "2.0", ID: req.ID, Result: result})
+	}
+	notify := func(method string, params interface{}) error {
+		return write(Response{JSONRPC: "2.0", Method: method, Params: params})
+	}
+
+	if err := fn(req.Params, respond, notify, r.Context().Done()); err != nil {
+		write(Response{JSONRPC: "2.0", ID: req.ID, Error: &Error{Code: -32000, Message: err.Error()}})
+	}
+}
+
+func writeResponse(w http.ResponseWriter, id json.RawMessage, result interface{}, rpcErr *Error) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
@@ -0,0 +1,246 @@
+// Package correlation pairs a captured send event with its subsequent
+// recv so the agent can emit RPC-level latency (rather than just raw
+// per-packet size) and detect requests that never got a response.
+package correlation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// DefaultTTL is how long a request is tracked before it's considered
+// orphaned and reported as a rpc_timeout feature.
+const DefaultTTL = 30 * time.Second
+
+// timeoutQueueCapacity bounds how many evicted-but-unmatched requests can
+// be queued for onTimeout before handleEvict starts dropping them. It
+// only needs to absorb a burst; onTimeout draining is expected to keep up
+// in steady state.
+const timeoutQueueCapacity = 256
+
+// Request is the send-side state tracked for one in-flight RPC call.
+type Request struct {
+	SessionKey   string
+	DestHostname string
+	Method       string
+	ParamsHash   string
+	BytesOut     uint32
+	SentAtNs     uint64
+}
+
+// Result is produced once a Request is matched to its response (a
+// rpc_latency_ms feature) or evicted unmatched (a rpc_timeout feature).
+type Result struct {
+	SessionKey     string
+	DestHostname   string
+	Method         string
+	ParamsHash     string
+	BytesOut       uint32
+	BytesIn        uint32
+	ResponseStatus int
+	LatencyMs      float64
+	TimedOut       bool
+}
+
+// Correlator pairs a send event with its subsequent recv, keyed by
+// (pid, 4-tuple, jsonrpc_id) when the caller has a jsonrpc id available,
+// falling back to strict FIFO order per session (sessionKey) when it
+// isn't — e.g. a notification-style payload, or a protocol this agent
+// doesn't parse ids for.
+type Correlator struct {
+	mu       sync.Mutex
+	pending  *lru.LRU[string, Request]
+	fifo     map[string][]string // sessionKey -> ordered pending keys with no id
+	fifoSeq  atomic.Uint64       // global counter minting unique no-id keys across all sessions
+	suppress map[string]bool     // keys removed via a match, so the evict callback ignores them
+
+	metricsMu sync.Mutex
+	latencies map[string][]float64 // method -> rolling window of matched-response latencies, for Snapshot
+
+	onTimeout func(Result)
+	timeouts  chan Result // buffered handoff so handleEvict never calls onTimeout inline
+}
+
+// New creates a Correlator whose entries expire after ttl. onTimeout is
+// called for every request evicted without a matching response, from a
+// dedicated goroutine this Correlator owns rather than from the LRU's
+// background janitor — onTimeout is typically wired to a publisher, and
+// calling it directly from the janitor would let a slow/down publisher
+// backend stall the janitor's eviction loop, which in turn blocks every
+// other caller contending for the LRU's internal lock (TrackRequest,
+// MatchResponse). If onTimeout falls behind by more than
+// timeoutQueueCapacity results, newly evicted results are dropped (the
+// already-queued ones are kept) rather than blocking eviction.
+func New(ttl time.Duration, onTimeout func(Result)) *Correlator {
+	c := &Correlator{
+		fifo:      make(map[string][]string),
+		suppress:  make(map[string]bool),
+		onTimeout: onTimeout,
+	}
+	c.pending = lru.NewLRU[string, Request](0, c.handleEvict, ttl)
+	if onTimeout != nil {
+		c.timeouts = make(chan Result, timeoutQueueCapacity)
+		go c.drainTimeouts()
+	}
+	return c
+}
+
+// drainTimeouts runs for the lifetime of the Correlator, invoking
+// onTimeout for every result handleEvict hands off.
+func (c *Correlator) drainTimeouts() {
+	for result := range c.timeouts {
+		c.onTimeout(result)
+	}
+}
+
+// TrackRequest records the send side of an RPC call. jsonrpcID may be
+// empty when the protocol carries no id (or the parser didn't extract
+// one); the call is then matched FIFO against later MatchResponse calls
+// on the same sessionKey.
+func (c *Correlator) TrackRequest(sessionKey, destHostname, jsonrpcID, method, paramsHash string, bytesOut uint32, sentAtNs uint64) {
+	req := Request{
+		SessionKey:   sessionKey,
+		DestHostname: destHostname,
+		Method:       method,
+		ParamsHash:   paramsHash,
+		BytesOut:     bytesOut,
+		SentAtNs:     sentAtNs,
+	}
+
+	if jsonrpcID != "" {
+		c.pending.Add(idKey(sessionKey, jsonrpcID), req)
+		return
+	}
+
+	key := fifoKey(sessionKey, c.fifoSeq.Add(1))
+	c.mu.Lock()
+	c.fifo[sessionKey] = append(c.fifo[sessionKey], key)
+	c.mu.Unlock()
+
+	c.pending.Add(key, req)
+}
+
+// MatchResponse pairs a recv event with its tracked request and returns
+// the resulting latency Result. ok is false when no tracked request
+// could be matched (already evicted, or none was ever seen).
+func (c *Correlator) MatchResponse(sessionKey, jsonrpcID string, bytesIn uint32, recvAtNs uint64, responseStatus int) (Result, bool) {
+	key := idKey(sessionKey, jsonrpcID)
+	req, ok := c.pending.Peek(key)
+	if jsonrpcID == "" || !ok {
+		var fifoOK bool
+		key, fifoOK = c.popFIFO(sessionKey)
+		if !fifoOK {
+			return Result{}, false
+		}
+		req, ok = c.pending.Peek(key)
+		if !ok {
+			return Result{}, false
+		}
+	}
+
+	c.mu.Lock()
+	c.suppress[key] = true
+	c.mu.Unlock()
+	c.pending.Remove(key)
+
+	result := Result{
+		SessionKey:     req.SessionKey,
+		DestHostname:   req.DestHostname,
+		Method:         req.Method,
+		ParamsHash:     req.ParamsHash,
+		BytesOut:       req.BytesOut,
+		BytesIn:        bytesIn,
+		ResponseStatus: responseStatus,
+		LatencyMs:      float64(recvAtNs-req.SentAtNs) / float64(time.Millisecond),
+	}
+	c.recordLatency(result.Method, result.LatencyMs)
+	return result, true
+}
+
+// popFIFO pops the oldest pending key queued for sessionKey. Once a
+// session's queue drains to empty, its fifo entry is dropped entirely
+// rather than left behind as an empty slice forever — otherwise the map
+// would grow by one entry for every distinct sessionKey (new PID, new
+// destination) ever seen over the agent's lifetime, unlike pending,
+// which is bounded by the LRU's TTL. A later TrackRequest on the same
+// sessionKey just recreates the entry; this is safe to do without also
+// resetting fifoSeq, because fifoSeq is a single counter shared across
+// every session (not a per-session one), so a key minted after the
+// queue drains can never collide with one minted before — even if a
+// concurrent TrackRequest/MatchResponse pair for the same sessionKey
+// raced with this pop.
+func (c *Correlator) popFIFO(sessionKey string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queue := c.fifo[sessionKey]
+	if len(queue) == 0 {
+		return "", false
+	}
+	key := queue[0]
+	queue = queue[1:]
+	if len(queue) == 0 {
+		delete(c.fifo, sessionKey)
+	} else {
+		c.fifo[sessionKey] = queue
+	}
+	return key, true
+}
+
+func (c *Correlator) handleEvict(key string, req Request) {
+	c.mu.Lock()
+	if c.suppress[key] {
+		delete(c.suppress, key)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	if c.timeouts == nil {
+		return
+	}
+	result := Result{
+		SessionKey:   req.SessionKey,
+		DestHostname: req.DestHostname,
+		Method:       req.Method,
+		ParamsHash:   req.ParamsHash,
+		BytesOut:     req.BytesOut,
+		TimedOut:     true,
+	}
+	select {
+	case c.timeouts <- result:
+	default: // drainTimeouts is falling behind; drop rather than block eviction
+	}
+}
+
+// HashParams returns a short, stable hash of a JSON-RPC params value
+// suitable for grouping calls by argument shape without logging the
+// (possibly sensitive) arguments themselves.
+func HashParams(params interface{}) string {
+	var raw []byte
+	switch v := params.(type) {
+	case nil:
+	case json.RawMessage:
+		raw = v
+	case []byte:
+		raw = v
+	default:
+		raw = []byte(fmt.Sprintf("%v", v))
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+func idKey(sessionKey, jsonrpcID string) string {
+	return sessionKey + ":id:" + jsonrpcID
+}
+
+func fifoKey(sessionKey string, seq uint64) string {
+	return fmt.Sprintf("%s:seq:%d", sessionKey, seq)
+}
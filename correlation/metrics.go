@@ -0,0 +1,75 @@
+package correlation
+
+import (
+	"math"
+	"sort"
+)
+
+// latencyWindow caps how many recent samples are kept per method for
+// percentile calculation; bounding it keeps memory flat regardless of
+// how long the agent has been running.
+const latencyWindow = 256
+
+// MethodMetrics summarizes the matched-response latencies seen for one
+// RPC method.
+type MethodMetrics struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P90Ms float64 `json:"p90_ms"`
+	P99Ms float64 `json:"p99_ms"`
+}
+
+// recordLatency appends a matched response's latency to its method's
+// rolling window, evicting the oldest sample once latencyWindow is
+// exceeded.
+func (c *Correlator) recordLatency(method string, latencyMs float64) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+
+	if c.latencies == nil {
+		c.latencies = make(map[string][]float64)
+	}
+	samples := append(c.latencies[method], latencyMs)
+	if len(samples) > latencyWindow {
+		samples = samples[len(samples)-latencyWindow:]
+	}
+	c.latencies[method] = samples
+}
+
+// Snapshot returns, for each method, the number of matched responses in
+// its rolling window (at most latencyWindow; not a running total) and the
+// latency percentiles (p50/p90/p99) computed over those responses.
+func (c *Correlator) Snapshot() map[string]MethodMetrics {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+
+	out := make(map[string]MethodMetrics, len(c.latencies))
+	for method, samples := range c.latencies {
+		sorted := append([]float64(nil), samples...)
+		sort.Float64s(sorted)
+		out[method] = MethodMetrics{
+			Count: len(sorted),
+			P50Ms: percentile(sorted, 0.50),
+			P90Ms: percentile(sorted, 0.90),
+			P99Ms: percentile(sorted, 0.99),
+		}
+	}
+	return out
+}
+
+// percentile returns the p-th percentile (0 < p <= 1) of sorted, which
+// must already be sorted ascending. Uses nearest-rank, which is good
+// enough for an operational snapshot without pulling in a stats library.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
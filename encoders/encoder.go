@@ -0,0 +1,45 @@
+// Package encoders abstracts how a MonitoringFeature is serialized
+// before being handed to a Publisher, so the wire format isn't locked to
+// the agent's original ad-hoc JSON.
+package encoders
+
+import (
+	"fmt"
+	"time"
+)
+
+// Feature is the protocol-agnostic shape Encoder implementations
+// serialize. It mirrors Agent's MonitoringFeature field-for-field; this
+// package can't import package main (it isn't importable), so
+// agent_main.go converts between the two at the call site.
+type Feature struct {
+	AppID       string
+	Protocol    string
+	FeatureType string
+	Timestamp   time.Time
+	Value       float64
+	ContextHash string
+	Details     map[string]interface{}
+}
+
+// Encoder serializes a Feature for publishing. The returned content type
+// (e.g. "application/json", "application/senml+cbor") lets a Publisher
+// set it on backends that support per-message metadata.
+type Encoder interface {
+	Name() string
+	Encode(f Feature) (payload []byte, contentType string, err error)
+}
+
+// New constructs the Encoder selected by the OUTPUT_FORMAT env var.
+func New(format string) (Encoder, error) {
+	switch format {
+	case "", "json":
+		return &JSONEncoder{}, nil
+	case "senml":
+		return &SenMLEncoder{}, nil
+	case "senml+cbor":
+		return &SenMLCBOREncoder{}, nil
+	default:
+		return nil, fmt.Errorf("encoders: unknown OUTPUT_FORMAT %q", format)
+	}
+}
@@ -0,0 +1,42 @@
+package encoders
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JSONEncoder reproduces the agent's original ad-hoc JSON wire format.
+type JSONEncoder struct{}
+
+// Name implements Encoder.
+func (e *JSONEncoder) Name() string { return "json" }
+
+// Encode implements Encoder.
+func (e *JSONEncoder) Encode(f Feature) ([]byte, string, error) {
+	data, err := json.Marshal(jsonFeature{
+		AppID:       f.AppID,
+		Protocol:    f.Protocol,
+		FeatureType: f.FeatureType,
+		Timestamp:   f.Timestamp,
+		Value:       f.Value,
+		ContextHash: f.ContextHash,
+		Details:     f.Details,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("json: marshaling feature: %w", err)
+	}
+	return data, "application/json", nil
+}
+
+// jsonFeature carries the wire tags; keeping it separate from Feature
+// means Feature itself stays free of any one encoding's concerns.
+type jsonFeature struct {
+	AppID       string                 `json:"app_id"`
+	Protocol    string                 `json:"protocol"`
+	FeatureType string                 `json:"feature_type"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Value       float64                `json:"value"`
+	ContextHash string                 `json:"context_hash"`
+	Details     map[string]interface{} `json:"details"`
+}
@@ -0,0 +1,111 @@
+package encoders
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// senMLRecord is one entry of an RFC 8428 SenML Pack. bn/bt (base name,
+// base time) are only populated on the first record of a pack; every
+// other record in the same pack is relative to them. This shape backs
+// the JSON representation directly; SenMLCBOREncoder converts it to
+// senMLCBORRecord, which carries RFC 8428 §6's integer map keys instead
+// of these JSON string labels.
+type senMLRecord struct {
+	BaseName    string   `json:"bn,omitempty"`
+	BaseTime    float64  `json:"bt,omitempty"`
+	Name        string   `json:"n,omitempty"`
+	Unit        string   `json:"u,omitempty"`
+	Value       *float64 `json:"v,omitempty"`
+	StringValue string   `json:"vs,omitempty"`
+}
+
+// SenMLEncoder implements RFC 8428 SenML-over-JSON.
+type SenMLEncoder struct{}
+
+// Name implements Encoder.
+func (e *SenMLEncoder) Name() string { return "senml" }
+
+// Encode implements Encoder.
+func (e *SenMLEncoder) Encode(f Feature) ([]byte, string, error) {
+	data, err := json.Marshal(senMLRecords(f))
+	if err != nil {
+		return nil, "", fmt.Errorf("senml: marshaling records: %w", err)
+	}
+	return data, "application/senml+json", nil
+}
+
+// senMLRecords builds the SenML Pack for f: one record for the feature
+// itself (bn = AppID, bt = base time from Timestamp, n = FeatureType,
+// u = unit inferred from the feature type, v = Value), followed by one
+// record per Details entry.
+func senMLRecords(f Feature) []senMLRecord {
+	records := []senMLRecord{{
+		BaseName: f.AppID,
+		BaseTime: float64(f.Timestamp.UnixNano()) / 1e9,
+		Name:     f.FeatureType,
+		Unit:     unitForFeatureType(f.FeatureType),
+		Value:    floatPtr(f.Value),
+	}}
+
+	keys := make([]string, 0, len(f.Details))
+	for k := range f.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		records = append(records, detailRecord(k, f.Details[k]))
+	}
+	return records
+}
+
+func detailRecord(name string, value interface{}) senMLRecord {
+	switch v := value.(type) {
+	case float64:
+		return senMLRecord{Name: name, Value: floatPtr(v)}
+	case float32:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case int:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case int8:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case int16:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case int32:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case int64:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case uint:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case uint8:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case uint16:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case uint32:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case uint64:
+		return senMLRecord{Name: name, Value: floatPtr(float64(v))}
+	case string:
+		return senMLRecord{Name: name, StringValue: v}
+	default:
+		return senMLRecord{Name: name, StringValue: fmt.Sprintf("%v", v)}
+	}
+}
+
+// unitForFeatureType maps the agent's feature-type naming convention to
+// a SenML unit symbol; unrecognized feature types carry no unit.
+func unitForFeatureType(featureType string) string {
+	switch {
+	case strings.HasSuffix(featureType, "_ms"):
+		return "ms"
+	case strings.HasSuffix(featureType, "_size"):
+		return "By"
+	default:
+		return ""
+	}
+}
+
+func floatPtr(v float64) *float64 { return &v }
@@ -0,0 +1,57 @@
+package encoders
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// senMLCBORRecord is one entry of an RFC 8428 SenML Pack in its CBOR
+// representation. §6 of the RFC requires CBOR packs to key each field by
+// the integer labels registered there (bn=-2, bt=-3, n=0, u=1, v=2,
+// vs=3) rather than the JSON representation's short string names, so a
+// spec-compliant consumer can tell the two apart on the wire. The
+// "keyasint" tag option tells fxamacker/cbor to emit an integer map key
+// for each field instead of a string one.
+type senMLCBORRecord struct {
+	BaseName    string   `cbor:"-2,keyasint,omitempty"`
+	BaseTime    float64  `cbor:"-3,keyasint,omitempty"`
+	Name        string   `cbor:"0,keyasint,omitempty"`
+	Unit        string   `cbor:"1,keyasint,omitempty"`
+	Value       *float64 `cbor:"2,keyasint,omitempty"`
+	StringValue string   `cbor:"3,keyasint,omitempty"`
+}
+
+// toCBORRecord converts a senMLRecord (the JSON-keyed shape shared with
+// SenMLEncoder) to its integer-keyed CBOR counterpart.
+func toCBORRecord(r senMLRecord) senMLCBORRecord {
+	return senMLCBORRecord{
+		BaseName:    r.BaseName,
+		BaseTime:    r.BaseTime,
+		Name:        r.Name,
+		Unit:        r.Unit,
+		Value:       r.Value,
+		StringValue: r.StringValue,
+	}
+}
+
+// SenMLCBOREncoder implements RFC 8428 SenML-over-CBOR ("senml+cbor").
+type SenMLCBOREncoder struct{}
+
+// Name implements Encoder.
+func (e *SenMLCBOREncoder) Name() string { return "senml+cbor" }
+
+// Encode implements Encoder.
+func (e *SenMLCBOREncoder) Encode(f Feature) ([]byte, string, error) {
+	records := senMLRecords(f)
+	cborRecords := make([]senMLCBORRecord, len(records))
+	for i, r := range records {
+		cborRecords[i] = toCBORRecord(r)
+	}
+
+	data, err := cbor.Marshal(cborRecords)
+	if err != nil {
+		return nil, "", fmt.Errorf("senml+cbor: marshaling records: %w", err)
+	}
+	return data, "application/senml+cbor", nil
+}
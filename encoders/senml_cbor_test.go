@@ -0,0 +1,56 @@
+package encoders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// TestSenMLCBOREncoderUsesIntegerLabels guards against the CBOR encoder
+// regressing to JSON's string labels: RFC 8428 §6 requires the CBOR
+// representation of a SenML Pack to key bn/bt/n/u/v/vs by their
+// registered integers (-2/-3/0/1/2/3), not by name.
+func TestSenMLCBOREncoderUsesIntegerLabels(t *testing.T) {
+	f := Feature{
+		AppID:       "arbitrum-node-service",
+		FeatureType: "rpc_latency_ms",
+		Timestamp:   time.Unix(1700000000, 0),
+		Value:       12.5,
+		Details: map[string]interface{}{
+			"method": "eth_call",
+		},
+	}
+
+	enc := &SenMLCBOREncoder{}
+	data, contentType, err := enc.Encode(f)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if contentType != "application/senml+cbor" {
+		t.Errorf("contentType = %q, want application/senml+cbor", contentType)
+	}
+
+	var records []map[int]interface{}
+	if err := cbor.Unmarshal(data, &records); err != nil {
+		t.Fatalf("decoding as []map[int]interface{}: %v (labels weren't integer-keyed)", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("len(records) = %d, want 2", len(records))
+	}
+
+	base := records[0]
+	for _, label := range []int{-2, -3, 0, 1, 2} {
+		if _, ok := base[label]; !ok {
+			t.Errorf("base record missing label %d: %v", label, base)
+		}
+	}
+
+	detail := records[1]
+	if _, ok := detail[3]; !ok {
+		t.Errorf("detail record missing label 3 (vs): %v", detail)
+	}
+	if name, _ := detail[0].(string); name != "method" {
+		t.Errorf("detail record label 0 (n) = %q, want %q", name, "method")
+	}
+}
@@ -0,0 +1,32 @@
+package encoders
+
+import "testing"
+
+// TestDetailRecordSizedIntTypes guards against a sized int/uint Details
+// value (e.g. the uint16 dest_port the agent attaches to its per-event features)
+// falling through to the string (vs) branch instead of the numeric (v)
+// one.
+func TestDetailRecordSizedIntTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  float64
+	}{
+		{"uint16 dest_port", uint16(8545), 8545},
+		{"uint8", uint8(200), 200},
+		{"int8", int8(-12), -12},
+		{"int16", int16(-1234), -1234},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := detailRecord(tt.name, tt.value)
+			if rec.Value == nil {
+				t.Fatalf("detailRecord(%v) encoded as string %q, want a numeric record", tt.value, rec.StringValue)
+			}
+			if *rec.Value != tt.want {
+				t.Errorf("detailRecord(%v).Value = %v, want %v", tt.value, *rec.Value, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package parsers
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// trustPrivateIPs controls whether loopback/RFC1918/link-local addresses
+// found in forwarding headers are accepted as the real client IP, rather
+// than skipped as probably meaningless (e.g. a sidecar proxy hop). Off
+// by default, since Arbitrum/geth traffic usually traverses a local
+// reverse proxy whose own address isn't the one operators want.
+var trustPrivateIPs = os.Getenv("TRUST_PRIVATE_IPS") == "true"
+
+// ClientIP walks header in the priority order the ingress ecosystem
+// commonly agrees on and returns the first address that looks like a
+// legitimate upstream client: X-Real-IP, then the leftmost non-private
+// hop in X-Forwarded-For, then the `for=` parameter of a Forwarded
+// header (RFC 7239). Returns "" if none qualify.
+func ClientIP(header http.Header) string {
+	if ip := validCandidate(header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+
+	if xff := header.Get("X-Forwarded-For"); xff != "" {
+		for _, hop := range strings.Split(xff, ",") {
+			if ip := validCandidate(strings.TrimSpace(hop)); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	if fwd := header.Get("Forwarded"); fwd != "" {
+		if ip := validCandidate(forwardedFor(fwd)); ip != "" {
+			return ip
+		}
+	}
+
+	return ""
+}
+
+// forwardedFor extracts the first for= token from an RFC 7239 Forwarded
+// header value.
+func forwardedFor(header string) string {
+	// Forwarded can list several hops separated by commas, each a
+	// semicolon-separated set of key=value pairs; take the first hop's
+	// for= value.
+	firstHop := header
+	if idx := strings.Index(header, ","); idx != -1 {
+		firstHop = header[:idx]
+	}
+
+	for _, part := range strings.Split(firstHop, ";") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(strings.ToLower(part), "for=") {
+			continue
+		}
+		return strings.Trim(part[len("for="):], `"`)
+	}
+	return ""
+}
+
+// unwrapHostPort strips a trailing :port and, for IPv6, the []
+// brackets RFC 7239 / X-Forwarded-For wrap bracketed addresses in.
+func unwrapHostPort(value string) string {
+	if strings.HasPrefix(value, "[") {
+		if end := strings.Index(value, "]"); end != -1 {
+			return value[1:end]
+		}
+		return value
+	}
+	// A bare IPv6 address also contains colons, so only strip a port
+	// off something that looks like host:port (exactly one colon).
+	if strings.Count(value, ":") == 1 {
+		return value[:strings.Index(value, ":")]
+	}
+	return value
+}
+
+func validCandidate(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	ip := net.ParseIP(unwrapHostPort(raw))
+	if ip == nil {
+		return ""
+	}
+	if !trustPrivateIPs && isPrivate(ip) {
+		return ""
+	}
+	return ip.String()
+}
+
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate()
+}
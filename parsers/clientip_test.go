@@ -0,0 +1,160 @@
+package parsers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func header(pairs ...string) http.Header {
+	h := make(http.Header)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h.Set(pairs[i], pairs[i+1])
+	}
+	return h
+}
+
+func TestClientIPHeaderPriority(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "X-Real-IP wins over X-Forwarded-For",
+			header: header("X-Real-IP", "203.0.113.9", "X-Forwarded-For", "198.51.100.1"),
+			want:   "203.0.113.9",
+		},
+		{
+			name:   "X-Forwarded-For used when X-Real-IP absent",
+			header: header("X-Forwarded-For", "203.0.113.9"),
+			want:   "203.0.113.9",
+		},
+		{
+			name:   "Forwarded used when neither X-Real-IP nor X-Forwarded-For present",
+			header: header("Forwarded", `for="203.0.113.9";proto=https`),
+			want:   "203.0.113.9",
+		},
+		{
+			name:   "X-Forwarded-For wins over Forwarded",
+			header: header("X-Forwarded-For", "203.0.113.9", "Forwarded", `for="198.51.100.1"`),
+			want:   "203.0.113.9",
+		},
+		{
+			name:   "no forwarding headers",
+			header: header(),
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientIP(tt.header); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPMultipleForwardedForHops(t *testing.T) {
+	tests := []struct {
+		name string
+		xff  string
+		want string
+	}{
+		{
+			name: "leftmost hop is the real client, rest are proxies",
+			xff:  "203.0.113.9, 10.0.0.1, 10.0.0.2",
+			want: "203.0.113.9",
+		},
+		{
+			name: "leftmost hop private, falls through to first public hop",
+			xff:  "127.0.0.1, 203.0.113.9",
+			want: "203.0.113.9",
+		},
+		{
+			name: "all hops private and untrusted",
+			xff:  "10.0.0.1, 192.168.1.1",
+			want: "",
+		},
+		{
+			name: "spoofed leading public-looking garbage mixed with real hops still picks leftmost valid IP",
+			xff:  "not-an-ip, 203.0.113.9, 10.0.0.1",
+			want: "203.0.113.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := header("X-Forwarded-For", tt.xff)
+			if got := ClientIP(h); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPTrustsPrivateWhenConfigured(t *testing.T) {
+	old := trustPrivateIPs
+	trustPrivateIPs = true
+	defer func() { trustPrivateIPs = old }()
+
+	h := header("X-Forwarded-For", "10.0.0.1")
+	if got := ClientIP(h); got != "10.0.0.1" {
+		t.Errorf("ClientIP() = %q, want 10.0.0.1 with TRUST_PRIVATE_IPS set", got)
+	}
+}
+
+func TestUnwrapHostPort(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"bare IPv4", "203.0.113.9", "203.0.113.9"},
+		{"IPv4 with port", "203.0.113.9:443", "203.0.113.9"},
+		{"bare IPv6", "2001:db8::1", "2001:db8::1"},
+		{"IPv6 in brackets, no port", "[2001:db8::1]", "2001:db8::1"},
+		{"IPv6 in brackets with port", "[2001:db8::1]:443", "2001:db8::1"},
+		{"unterminated bracket passes through unchanged", "[2001:db8::1", "[2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unwrapHostPort(tt.value); got != tt.want {
+				t.Errorf("unwrapHostPort(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPIPv6Forms(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "X-Real-IP bare IPv6",
+			header: header("X-Real-IP", "2001:db8::9"),
+			want:   "2001:db8::9",
+		},
+		{
+			name:   "X-Forwarded-For bracketed IPv6 with port",
+			header: header("X-Forwarded-For", "[2001:db8::9]:443"),
+			want:   "2001:db8::9",
+		},
+		{
+			name:   "Forwarded bracketed IPv6 for= value",
+			header: header("Forwarded", `for="[2001:db8::9]:443"`),
+			want:   "2001:db8::9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientIP(tt.header); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
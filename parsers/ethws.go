@@ -0,0 +1,152 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	Register(&EthWSParser{})
+}
+
+// wsOpcodeText is the RFC 6455 opcode for a frame carrying a UTF-8 text
+// payload, which is how geth's websocket RPC endpoint sends JSON-RPC.
+const wsOpcodeText = 0x1
+
+// ethSubscribeRequest mirrors an eth_subscribe / eth_unsubscribe call as
+// sent over a geth websocket connection.
+type ethSubscribeRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// ethSubscribeNotification mirrors the `eth_subscription` notification geth
+// pushes for each new event on a live subscription.
+type ethSubscribeNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string `json:"subscription"`
+	} `json:"params"`
+}
+
+// EthWSParser decodes the eth_subscribe/eth_unsubscribe/eth_subscription
+// framing used by geth's websocket RPC endpoint. Captured payloads carry
+// a raw RFC 6455 frame (client→server frames are masked, server→client
+// frames aren't), so Detect/Parse unwrap that framing via
+// unwrapWebSocketFrame before looking at the JSON-RPC envelope inside.
+// Registration order with JSONRPCParser doesn't matter: JSONRPCParser's
+// Detect only claims a body that starts with a JSON object or array, so
+// it never claims a WebSocket frame. EthWSParser's Detect only claims
+// frames carrying the subscription methods; other JSON-RPC calls sent in
+// WebSocket frames are claimed by neither of the two.
+type EthWSParser struct{}
+
+// Name implements Parser.
+func (p *EthWSParser) Name() string { return "eth_ws" }
+
+// Detect implements Parser.
+func (p *EthWSParser) Detect(payload []byte, destPort uint16) bool {
+	body, ok := unwrapWebSocketFrame(payload)
+	if !ok {
+		return false
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return false
+	}
+	return bytes.Contains(body, []byte(`"eth_subscribe"`)) ||
+		bytes.Contains(body, []byte(`"eth_unsubscribe"`)) ||
+		bytes.Contains(body, []byte(`"eth_subscription"`))
+}
+
+// Parse implements Parser.
+func (p *EthWSParser) Parse(sessionKey string, isReq bool, data []byte) ([]ParsedEvent, error) {
+	body, ok := unwrapWebSocketFrame(data)
+	if !ok {
+		return nil, fmt.Errorf("eth_ws: no text frame found in captured payload")
+	}
+
+	if bytes.Contains(body, []byte(`"eth_subscription"`)) {
+		var note ethSubscribeNotification
+		if err := json.Unmarshal(body, &note); err != nil {
+			return nil, fmt.Errorf("eth_ws: decoding subscription notification: %w", err)
+		}
+		return []ParsedEvent{{
+			Method: note.Method,
+			Tags:   map[string]string{"subscription": note.Params.Subscription},
+		}}, nil
+	}
+
+	var req ethSubscribeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("eth_ws: decoding subscribe/unsubscribe call: %w", err)
+	}
+	ev := ParsedEvent{Method: req.Method}
+	if len(req.Params) > 0 {
+		var topic string
+		if err := json.Unmarshal(req.Params[0], &topic); err == nil {
+			ev.Tags = map[string]string{"subscription_type": topic}
+		}
+	}
+	return []ParsedEvent{ev}, nil
+}
+
+// unwrapWebSocketFrame decodes a single RFC 6455 frame, unmasking its
+// payload when the frame carries a masking key (always true for
+// client→server frames; never true for server→client ones), and returns
+// it along with whether payload held a complete, well-formed text frame.
+// Anything else (a non-text opcode, a truncated frame) reports ok=false
+// so callers fall through to other parsers rather than misreading
+// framing bytes as JSON.
+func unwrapWebSocketFrame(payload []byte) (body []byte, ok bool) {
+	if len(payload) < 2 {
+		return nil, false
+	}
+	if payload[0]&0x0f != wsOpcodeText {
+		return nil, false
+	}
+
+	masked := payload[1]&0x80 != 0
+	length := int(payload[1] & 0x7f)
+	off := 2
+
+	switch length {
+	case 126:
+		if len(payload) < off+2 {
+			return nil, false
+		}
+		length = int(payload[off])<<8 | int(payload[off+1])
+		off += 2
+	case 127:
+		if len(payload) < off+8 {
+			return nil, false
+		}
+		length = 0
+		for _, b := range payload[off : off+8] {
+			length = length<<8 | int(b)
+		}
+		off += 8
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if len(payload) < off+4 {
+			return nil, false
+		}
+		copy(maskKey[:], payload[off:off+4])
+		off += 4
+	}
+
+	if len(payload) < off+length {
+		return nil, false
+	}
+	body = append([]byte(nil), payload[off:off+length]...)
+	if masked {
+		for i := range body {
+			body[i] ^= maskKey[i%4]
+		}
+	}
+	return body, true
+}
@@ -0,0 +1,156 @@
+package parsers
+
+import "testing"
+
+// Fixtures below are the JSON-RPC bodies geth sends/receives over its
+// websocket RPC endpoint; encodeWSFrame wraps them in the RFC 6455
+// framing (masked for client→server, unmasked for server→client) that
+// actually appears on the wire, matching what the agent captures.
+const (
+	ethSubscribeCall      = `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}`
+	ethUnsubscribeCall    = `{"jsonrpc":"2.0","id":2,"method":"eth_unsubscribe","params":["0xcd0c3e8af590364c09d0fa6a1210faf5"]}`
+	ethSubscriptionNotify = `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xcd0c3e8af590364c09d0fa6a1210faf5","result":{"number":"0x112a880"}}}`
+)
+
+// encodeWSFrame builds a single RFC 6455 text frame carrying payload,
+// using the 16-bit extended length form once payload crosses the 125-byte
+// small-length boundary. Client→server frames are always masked; masked
+// selects that.
+func encodeWSFrame(payload []byte, masked bool) []byte {
+	var frame []byte
+	frame = append(frame, 0x80|wsOpcodeText) // FIN set, text opcode
+
+	var lengthByte byte
+	switch {
+	case len(payload) < 126:
+		lengthByte = byte(len(payload))
+	case len(payload) <= 0xffff:
+		lengthByte = 126
+	default:
+		panic("encodeWSFrame: fixture payload too long for this test helper")
+	}
+	if masked {
+		lengthByte |= 0x80
+	}
+	frame = append(frame, lengthByte)
+
+	if lengthByte&0x7f == 126 {
+		frame = append(frame, byte(len(payload)>>8), byte(len(payload)))
+	}
+
+	if !masked {
+		return append(frame, payload...)
+	}
+
+	maskKey := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, maskKey[:]...)
+	maskedPayload := make([]byte, len(payload))
+	for i, b := range payload {
+		maskedPayload[i] = b ^ maskKey[i%4]
+	}
+	return append(frame, maskedPayload...)
+}
+
+func TestEthWSParserDetect(t *testing.T) {
+	p := &EthWSParser{}
+	for _, payload := range []string{ethSubscribeCall, ethUnsubscribeCall} {
+		if !p.Detect(encodeWSFrame([]byte(payload), true), 8546) {
+			t.Errorf("Detect(%q) = false, want true", payload)
+		}
+	}
+	if !p.Detect(encodeWSFrame([]byte(ethSubscriptionNotify), false), 8546) {
+		t.Errorf("Detect(%q) = false, want true", ethSubscriptionNotify)
+	}
+
+	plainJSONRPC := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	if p.Detect(encodeWSFrame([]byte(plainJSONRPC), true), 8546) {
+		t.Error("Detect() = true for a plain eth_blockNumber call, want false (only subscription methods are claimed)")
+	}
+
+	// Unframed JSON (no WS frame header at all) must not be mistaken for
+	// a text frame.
+	if p.Detect([]byte(ethSubscribeCall), 8546) {
+		t.Error("Detect() = true for bare JSON with no WS framing, want false")
+	}
+}
+
+func TestEthWSParserParseSubscribe(t *testing.T) {
+	p := &EthWSParser{}
+	events, err := p.Parse("sess-1", true, encodeWSFrame([]byte(ethSubscribeCall), true))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].Method != "eth_subscribe" {
+		t.Errorf("Method = %q, want eth_subscribe", events[0].Method)
+	}
+	if events[0].Tags["subscription_type"] != "newHeads" {
+		t.Errorf("subscription_type tag = %q, want newHeads", events[0].Tags["subscription_type"])
+	}
+}
+
+func TestEthWSParserParseUnsubscribe(t *testing.T) {
+	p := &EthWSParser{}
+	events, err := p.Parse("sess-1", true, encodeWSFrame([]byte(ethUnsubscribeCall), true))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].Method != "eth_unsubscribe" {
+		t.Errorf("Method = %q, want eth_unsubscribe", events[0].Method)
+	}
+}
+
+func TestEthWSParserParseSubscriptionNotification(t *testing.T) {
+	p := &EthWSParser{}
+	events, err := p.Parse("sess-1", false, encodeWSFrame([]byte(ethSubscriptionNotify), false))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].Method != "eth_subscription" {
+		t.Errorf("Method = %q, want eth_subscription", events[0].Method)
+	}
+	if events[0].Tags["subscription"] != "0xcd0c3e8af590364c09d0fa6a1210faf5" {
+		t.Errorf("subscription tag = %q, want 0xcd0c3e8af590364c09d0fa6a1210faf5", events[0].Tags["subscription"])
+	}
+}
+
+func TestEthWSParserParseLongPayloadUses16BitLength(t *testing.T) {
+	p := &EthWSParser{}
+	// Pad params past the 125-byte small-length boundary so the frame
+	// must use the 16-bit extended length form.
+	padding := make([]byte, 200)
+	for i := range padding {
+		padding[i] = 'x'
+	}
+	payload := `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads","` + string(padding) + `"]}`
+
+	frame := encodeWSFrame([]byte(payload), true)
+	if frame[1]&0x7f != 126 {
+		t.Fatalf("test fixture bug: frame length byte = %d, want the 126 (16-bit length) marker", frame[1]&0x7f)
+	}
+
+	events, err := p.Parse("sess-1", true, frame)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 || events[0].Method != "eth_subscribe" {
+		t.Fatalf("events = %+v, want a single eth_subscribe event", events)
+	}
+}
+
+func TestEthWSParserParseRejectsNonTextOpcode(t *testing.T) {
+	p := &EthWSParser{}
+	// A close frame (opcode 0x8) must not be mistaken for a text frame.
+	frame := []byte{0x88, 0x00}
+	if _, err := p.Parse("sess-1", true, frame); err == nil {
+		t.Error("Parse() = nil error for a close frame, want error")
+	}
+}
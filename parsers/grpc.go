@@ -0,0 +1,96 @@
+package parsers
+
+import (
+	"fmt"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func init() {
+	Register(&GRPCParser{})
+}
+
+// grpcFramePreface is the fixed connection preface every HTTP/2 client
+// sends before its first frame; seeing it is an unambiguous signal that
+// the payload is HTTP/2 (and, on the ports this agent watches, gRPC).
+const grpcFramePreface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// GRPCParser recognizes HTTP/2 traffic and decodes the `:path` pseudo
+// header out of HEADERS frames via hpack, reporting it as Method. Only
+// the request direction carries a path; response-direction frames are
+// reported with whatever :status shows up.
+type GRPCParser struct{}
+
+// Name implements Parser.
+func (p *GRPCParser) Name() string { return "grpc" }
+
+// Detect implements Parser. It looks for the HTTP/2 connection preface
+// or, failing that, the header of any known HTTP/2 frame type (the first
+// captured frame on an already-established connection).
+func (p *GRPCParser) Detect(payload []byte, destPort uint16) bool {
+	if len(payload) >= len(grpcFramePreface) && string(payload[:len(grpcFramePreface)]) == grpcFramePreface {
+		return true
+	}
+	return looksLikeHTTP2Frame(payload)
+}
+
+// looksLikeHTTP2Frame reports whether payload begins with a plausible
+// HTTP/2 frame header (9 bytes: 24-bit length, 8-bit type, 8-bit flags,
+// 31-bit stream id) of a known frame type.
+func looksLikeHTTP2Frame(payload []byte) bool {
+	if len(payload) < 9 {
+		return false
+	}
+	frameType := payload[3]
+	return frameType <= byte(http2.FrameContinuation)
+}
+
+// Parse implements Parser. It walks the frames in data to the first
+// HEADERS frame and decodes that frame's header block fragment with
+// hpack, pulling out :path and :status. CONTINUATION frames are not
+// joined to it, so fields carried only in them are not seen.
+func (p *GRPCParser) Parse(sessionKey string, isReq bool, data []byte) ([]ParsedEvent, error) {
+	off := 0
+	if len(data) >= len(grpcFramePreface) && string(data[:len(grpcFramePreface)]) == grpcFramePreface {
+		off = len(grpcFramePreface)
+	}
+
+	for off+9 <= len(data) {
+		length := int(data[off])<<16 | int(data[off+1])<<8 | int(data[off+2])
+		frameType := data[off+3]
+		payloadStart := off + 9
+		payloadEnd := payloadStart + length
+		if payloadEnd > len(data) {
+			payloadEnd = len(data)
+		}
+
+		if http2.FrameType(frameType) == http2.FrameHeaders {
+			ev, err := decodeHeadersFrame(data[payloadStart:payloadEnd])
+			if err != nil {
+				return nil, fmt.Errorf("grpc: decoding HEADERS frame: %w", err)
+			}
+			return []ParsedEvent{ev}, nil
+		}
+
+		off = payloadEnd
+	}
+
+	return nil, fmt.Errorf("grpc: no HEADERS frame found in captured payload")
+}
+
+func decodeHeadersFrame(block []byte) (ParsedEvent, error) {
+	ev := ParsedEvent{Tags: map[string]string{}}
+	decoder := hpack.NewDecoder(4096, func(f hpack.HeaderField) {
+		switch f.Name {
+		case ":path":
+			ev.Method = f.Value
+		case ":status":
+			ev.Tags["grpc_status"] = f.Value
+		}
+	})
+	if _, err := decoder.Write(block); err != nil {
+		return ev, err
+	}
+	return ev, nil
+}
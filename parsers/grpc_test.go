@@ -0,0 +1,117 @@
+package parsers
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// encodeHeadersFrame builds a minimal HTTP/2 HEADERS frame (no padding,
+// no priority, END_HEADERS set) carrying fields, mirroring what a real
+// gRPC client sends for a unary call.
+func encodeHeadersFrame(fields []hpack.HeaderField) []byte {
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	for _, f := range fields {
+		enc.WriteField(f)
+	}
+
+	length := block.Len()
+	frame := make([]byte, 9+length)
+	frame[0] = byte(length >> 16)
+	frame[1] = byte(length >> 8)
+	frame[2] = byte(length)
+	frame[3] = 0x1 // FrameHeaders
+	frame[4] = 0x4 // END_HEADERS
+	// stream id left at 0; decodeHeadersFrame doesn't look at it
+	copy(frame[9:], block.Bytes())
+	return frame
+}
+
+func TestGRPCParserDetectPreface(t *testing.T) {
+	p := &GRPCParser{}
+	payload := []byte(grpcFramePreface + "rest of the connection")
+	if !p.Detect(payload, 9090) {
+		t.Error("Detect() = false for HTTP/2 preface, want true")
+	}
+}
+
+func TestGRPCParserDetectBareFrame(t *testing.T) {
+	p := &GRPCParser{}
+	frame := encodeHeadersFrame([]hpack.HeaderField{{Name: ":path", Value: "/eth.Node/Subscribe"}})
+	if !p.Detect(frame, 9090) {
+		t.Error("Detect() = false for a bare HEADERS frame, want true")
+	}
+	if p.Detect([]byte("GET / HTTP/1.1\r\n\r\n"), 9090) {
+		t.Error("Detect() = true for an HTTP/1.1 request, want false")
+	}
+}
+
+func TestGRPCParserParseRequestPath(t *testing.T) {
+	p := &GRPCParser{}
+	frame := encodeHeadersFrame([]hpack.HeaderField{
+		{Name: ":method", Value: "POST"},
+		{Name: ":path", Value: "/eth.Node/Subscribe"},
+		{Name: "content-type", Value: "application/grpc"},
+	})
+
+	events, err := p.Parse("sess-1", true, frame)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].Method != "/eth.Node/Subscribe" {
+		t.Errorf("Method = %q, want /eth.Node/Subscribe", events[0].Method)
+	}
+}
+
+func TestGRPCParserParseResponseStatus(t *testing.T) {
+	p := &GRPCParser{}
+	frame := encodeHeadersFrame([]hpack.HeaderField{
+		{Name: ":status", Value: "200"},
+		{Name: "content-type", Value: "application/grpc"},
+	})
+
+	events, err := p.Parse("sess-1", false, frame)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].Tags["grpc_status"] != "200" {
+		t.Errorf("grpc_status tag = %q, want 200", events[0].Tags["grpc_status"])
+	}
+}
+
+func TestGRPCParserParseWalksPastNonHeadersFrames(t *testing.T) {
+	p := &GRPCParser{}
+	// A SETTINGS frame (type 0x4) precedes the HEADERS frame, as it would
+	// on an already-established HTTP/2 connection.
+	settings := make([]byte, 9)
+	settings[3] = 0x4
+
+	headers := encodeHeadersFrame([]hpack.HeaderField{{Name: ":path", Value: "/eth.Node/Subscribe"}})
+
+	data := append(settings, headers...)
+	events, err := p.Parse("sess-1", true, data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 || events[0].Method != "/eth.Node/Subscribe" {
+		t.Fatalf("events = %+v, want a single /eth.Node/Subscribe event", events)
+	}
+}
+
+func TestGRPCParserParseNoHeadersFrame(t *testing.T) {
+	p := &GRPCParser{}
+	settings := make([]byte, 9)
+	settings[3] = 0x4
+
+	if _, err := p.Parse("sess-1", true, settings); err == nil {
+		t.Error("Parse() = nil error for a payload with no HEADERS frame, want error")
+	}
+}
@@ -0,0 +1,69 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+func init() {
+	Register(&HTTPParser{})
+}
+
+// HTTPParser recognizes a plain HTTP/1.x request or response line and
+// reports the request path (or response status) as the ParsedEvent
+// Method/StatusCode. Detect defers to JSONRPCParser whenever the body
+// carries a JSON-RPC envelope (the common case for geth's
+// JSON-RPC-over-HTTP), so registration order doesn't matter; HTTPParser
+// only claims everything else (health checks, REST admin endpoints, etc).
+type HTTPParser struct{}
+
+// Name implements Parser.
+func (p *HTTPParser) Name() string { return "http" }
+
+// Detect implements Parser.
+func (p *HTTPParser) Detect(payload []byte, destPort uint16) bool {
+	isHTTP := bytes.HasPrefix(payload, []byte("GET ")) ||
+		bytes.HasPrefix(payload, []byte("POST ")) ||
+		bytes.HasPrefix(payload, []byte("PUT ")) ||
+		bytes.HasPrefix(payload, []byte("DELETE ")) ||
+		bytes.HasPrefix(payload, []byte("HTTP/1."))
+	if !isHTTP {
+		return false
+	}
+
+	body := bytes.TrimSpace(stripHTTPHeaders(payload))
+	looksLikeJSONRPC := len(body) > 0 && (body[0] == '{' || body[0] == '[') && bytes.Contains(body, []byte(`"jsonrpc"`))
+	return !looksLikeJSONRPC
+}
+
+// Parse implements Parser.
+func (p *HTTPParser) Parse(sessionKey string, isReq bool, data []byte) ([]ParsedEvent, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	if isReq {
+		req, err := http.ReadRequest(r)
+		if err != nil {
+			return nil, fmt.Errorf("http: parsing request: %w", err)
+		}
+		ev := ParsedEvent{
+			Method: req.URL.Path,
+			Tags:   map[string]string{"http_method": req.Method},
+		}
+		// Arbitrum/geth traffic often traverses a local reverse proxy,
+		// so the captured DestIP is 127.0.0.1 and the real upstream is
+		// only visible in the forwarding headers.
+		if clientIP := ClientIP(req.Header); clientIP != "" {
+			ev.Details = map[string]interface{}{"upstream_ip": clientIP}
+		}
+		return []ParsedEvent{ev}, nil
+	}
+
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http: parsing response: %w", err)
+	}
+	return []ParsedEvent{{
+		StatusCode: resp.StatusCode,
+	}}, nil
+}
@@ -0,0 +1,65 @@
+package parsers
+
+import "testing"
+
+func TestHTTPParserDetect(t *testing.T) {
+	p := &HTTPParser{}
+
+	plainGET := "GET /debug/metrics HTTP/1.1\r\nHost: 127.0.0.1:6060\r\n\r\n"
+	if !p.Detect([]byte(plainGET), 6060) {
+		t.Error("Detect() = false for plain GET, want true")
+	}
+
+	// geth's JSON-RPC-over-HTTP traffic is HTTP on the wire, but should
+	// be left to JSONRPCParser.
+	jsonrpcOverHTTP := "POST / HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" +
+		`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	if p.Detect([]byte(jsonrpcOverHTTP), 8545) {
+		t.Error("Detect() = true for JSON-RPC-over-HTTP payload, want false (belongs to JSONRPCParser)")
+	}
+
+	if p.Detect([]byte(`{"jsonrpc":"2.0"}`), 8545) {
+		t.Error("Detect() = true for bare JSON payload, want false")
+	}
+}
+
+func TestHTTPParserParseRequest(t *testing.T) {
+	p := &HTTPParser{}
+	raw := "GET /debug/metrics?format=prometheus HTTP/1.1\r\n" +
+		"Host: 127.0.0.1:6060\r\n" +
+		"X-Forwarded-For: 203.0.113.9\r\n\r\n"
+
+	events, err := p.Parse("sess-1", true, []byte(raw))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	ev := events[0]
+	if ev.Method != "/debug/metrics" {
+		t.Errorf("Method = %q, want /debug/metrics", ev.Method)
+	}
+	if ev.Tags["http_method"] != "GET" {
+		t.Errorf("http_method tag = %q, want GET", ev.Tags["http_method"])
+	}
+	if ev.Details["upstream_ip"] != "203.0.113.9" {
+		t.Errorf("upstream_ip detail = %v, want 203.0.113.9", ev.Details["upstream_ip"])
+	}
+}
+
+func TestHTTPParserParseResponse(t *testing.T) {
+	p := &HTTPParser{}
+	raw := "HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\n\r\n"
+
+	events, err := p.Parse("sess-1", false, []byte(raw))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].StatusCode != 503 {
+		t.Errorf("StatusCode = %d, want 503", events[0].StatusCode)
+	}
+}
@@ -0,0 +1,128 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	Register(&JSONRPCParser{})
+}
+
+// jsonrpcRequest mirrors the JSON-RPC 2.0 request object. Params is kept
+// raw so callers can decode it against a method-specific shape later.
+type jsonrpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// jsonrpcResponse mirrors the JSON-RPC 2.0 response object.
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *jsonrpcError   `json:"error"`
+}
+
+type jsonrpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// JSONRPCParser decodes JSON-RPC 2.0 payloads, including batch requests
+// and responses, using encoding/json streaming rather than a regex so a
+// single batch yields one ParsedEvent per call.
+type JSONRPCParser struct{}
+
+// Name implements Parser.
+func (p *JSONRPCParser) Name() string { return "jsonrpc2" }
+
+// Detect implements Parser. It looks past any HTTP header block for a
+// top-level JSON object or array carrying a "jsonrpc" member.
+func (p *JSONRPCParser) Detect(payload []byte, destPort uint16) bool {
+	body := stripHTTPHeaders(payload)
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return false
+	}
+	return (body[0] == '{' || body[0] == '[') && bytes.Contains(body, []byte(`"jsonrpc"`))
+}
+
+// Parse implements Parser. It streams top-level JSON values out of data
+// (handling both a single object and a `[...]` batch array) and decodes
+// each as either a request or a response depending on isReq.
+func (p *JSONRPCParser) Parse(sessionKey string, isReq bool, data []byte) ([]ParsedEvent, error) {
+	body := stripHTTPHeaders(data)
+	dec := json.NewDecoder(bytes.NewReader(body))
+
+	// Consume a leading '[' if this is a batch; json.Decoder.Token lets
+	// us step through the array without buffering every element.
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("jsonrpc: empty payload: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); ok && delim == '[' {
+		return p.parseBatch(dec, isReq)
+	}
+
+	// Not a batch: re-decode the whole body as a single call.
+	return p.parseOne(body, isReq)
+}
+
+func (p *JSONRPCParser) parseBatch(dec *json.Decoder, isReq bool) ([]ParsedEvent, error) {
+	var events []ParsedEvent
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return events, fmt.Errorf("jsonrpc: decoding batch element: %w", err)
+		}
+		ev, err := p.parseOne(raw, isReq)
+		if err != nil {
+			continue // skip malformed calls, keep the rest of the batch
+		}
+		events = append(events, ev...)
+	}
+	return events, nil
+}
+
+func (p *JSONRPCParser) parseOne(raw json.RawMessage, isReq bool) ([]ParsedEvent, error) {
+	if isReq {
+		var req jsonrpcRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, fmt.Errorf("jsonrpc: decoding request: %w", err)
+		}
+		return []ParsedEvent{{
+			Method: req.Method,
+			Params: req.Params,
+			Tags:   map[string]string{"jsonrpc_id": string(req.ID)},
+		}}, nil
+	}
+
+	var resp jsonrpcResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("jsonrpc: decoding response: %w", err)
+	}
+	ev := ParsedEvent{
+		Tags: map[string]string{"jsonrpc_id": string(resp.ID)},
+	}
+	if resp.Error != nil {
+		ev.StatusCode = resp.Error.Code
+		ev.Details = map[string]interface{}{"error_message": resp.Error.Message}
+	}
+	return []ParsedEvent{ev}, nil
+}
+
+// stripHTTPHeaders drops everything up to and including the first blank
+// line (CRLF CRLF), which is how geth's JSON-RPC-over-HTTP payloads are
+// captured by the tracer. Payloads with no header block pass through
+// unchanged.
+func stripHTTPHeaders(payload []byte) []byte {
+	if sep := bytes.Index(payload, []byte("\r\n\r\n")); sep != -1 {
+		return payload[sep+4:]
+	}
+	return payload
+}
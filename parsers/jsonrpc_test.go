@@ -0,0 +1,114 @@
+package parsers
+
+import "testing"
+
+// Fixtures below are captured (reformatted for readability) from a geth
+// JSON-RPC-over-HTTP endpoint.
+const (
+	jsonrpcSingleRequest = "POST / HTTP/1.1\r\n" +
+		"Host: 127.0.0.1:8545\r\n" +
+		"Content-Type: application/json\r\n" +
+		"Content-Length: 63\r\n\r\n" +
+		`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+
+	jsonrpcSingleResponse = "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: application/json\r\n\r\n" +
+		`{"jsonrpc":"2.0","id":1,"result":"0x112a880"}`
+
+	jsonrpcBatchRequest = `[` +
+		`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]},` +
+		`{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"to":"0xabc"}]}` +
+		`]`
+
+	jsonrpcBatchResponse = `[` +
+		`{"jsonrpc":"2.0","id":1,"result":"0x112a880"},` +
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"execution reverted"}}` +
+		`]`
+)
+
+func TestJSONRPCParserDetect(t *testing.T) {
+	p := &JSONRPCParser{}
+	if !p.Detect([]byte(jsonrpcSingleRequest), 8545) {
+		t.Error("Detect() = false for single request, want true")
+	}
+	if !p.Detect([]byte(jsonrpcBatchRequest), 8545) {
+		t.Error("Detect() = false for batch request, want true")
+	}
+	if p.Detect([]byte("GET /health HTTP/1.1\r\n\r\n"), 8545) {
+		t.Error("Detect() = true for non-JSON-RPC payload, want false")
+	}
+}
+
+func TestJSONRPCParserParseSingle(t *testing.T) {
+	p := &JSONRPCParser{}
+
+	events, err := p.Parse("sess-1", true, []byte(jsonrpcSingleRequest))
+	if err != nil {
+		t.Fatalf("Parse(request): %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].Method != "eth_blockNumber" {
+		t.Errorf("Method = %q, want eth_blockNumber", events[0].Method)
+	}
+	if events[0].Tags["jsonrpc_id"] != "1" {
+		t.Errorf("jsonrpc_id tag = %q, want 1", events[0].Tags["jsonrpc_id"])
+	}
+
+	events, err = p.Parse("sess-1", false, []byte(jsonrpcSingleResponse))
+	if err != nil {
+		t.Fatalf("Parse(response): %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].StatusCode != 0 {
+		t.Errorf("StatusCode = %d, want 0 for a non-error response", events[0].StatusCode)
+	}
+}
+
+func TestJSONRPCParserParseBatch(t *testing.T) {
+	p := &JSONRPCParser{}
+
+	events, err := p.Parse("sess-1", true, []byte(jsonrpcBatchRequest))
+	if err != nil {
+		t.Fatalf("Parse(batch request): %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("len(events) = %d, want 2", len(events))
+	}
+	if events[0].Method != "eth_blockNumber" || events[1].Method != "eth_call" {
+		t.Errorf("batch methods = %q, %q", events[0].Method, events[1].Method)
+	}
+
+	events, err = p.Parse("sess-1", false, []byte(jsonrpcBatchResponse))
+	if err != nil {
+		t.Fatalf("Parse(batch response): %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("len(events) = %d, want 2", len(events))
+	}
+	if events[1].StatusCode != -32000 {
+		t.Errorf("second response StatusCode = %d, want -32000", events[1].StatusCode)
+	}
+}
+
+func TestJSONRPCParserParseBatchSkipsMalformedElement(t *testing.T) {
+	p := &JSONRPCParser{}
+	// The second element is syntactically valid JSON but has "method" as
+	// a number instead of a string, so parseOne fails to decode it;
+	// parseBatch should drop it and still return the first call.
+	batch := `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]},{"jsonrpc":"2.0","id":2,"method":123}]`
+
+	events, err := p.Parse("sess-1", true, []byte(batch))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1 (malformed element dropped)", len(events))
+	}
+	if events[0].Method != "eth_blockNumber" {
+		t.Errorf("Method = %q, want eth_blockNumber", events[0].Method)
+	}
+}
@@ -0,0 +1,78 @@
+// Package parsers provides a pluggable protocol-parser subsystem for the
+// eBPF agent. Each supported wire protocol (HTTP, JSON-RPC, gRPC, Ethereum
+// WebSocket, ...) implements the Parser interface in its own file and
+// registers itself at init time via Register. Agent.readAndProcessEvents
+// dispatches each captured payload through the registry instead of hard
+// coding protocol knowledge, so adding a protocol never touches the eBPF
+// loading path.
+package parsers
+
+import "fmt"
+
+// ParsedEvent is the protocol-agnostic result of parsing a captured
+// payload. Fields that don't apply to a given protocol are left zero.
+type ParsedEvent struct {
+	// Method is the RPC method / HTTP path / gRPC path the payload
+	// targets, e.g. "eth_call" or "/eth.Node/Subscribe".
+	Method string
+	// Params holds the call parameters, when the protocol has them
+	// (e.g. JSON-RPC "params", kept as raw JSON).
+	Params interface{}
+	// StatusCode is the protocol-level status/error code, if any
+	// (HTTP status, JSON-RPC error.code). GRPCParser leaves it zero and
+	// reports the HTTP/2 :status as the "grpc_status" tag instead.
+	StatusCode int
+	// Tags are short protocol-specific labels suitable for inclusion in
+	// a NATS subject or metric dimension.
+	Tags map[string]string
+	// Details carries any additional protocol-specific data that
+	// doesn't fit the fields above.
+	Details map[string]interface{}
+}
+
+// Parser recognizes and decodes payloads for a single wire protocol.
+type Parser interface {
+	// Name identifies the parser, e.g. "http", "jsonrpc2", "grpc".
+	Name() string
+	// Detect reports whether this parser understands payload, given the
+	// destination port observed on the connection. Detect must be cheap
+	// and side-effect free; it is called for every perf record until one
+	// parser claims it.
+	Detect(payload []byte, destPort uint16) bool
+	// Parse decodes data for the session identified by sessionKey.
+	// isReq is true for the request/send direction and false for the
+	// response/recv direction. A single send or recv may yield more than
+	// one logical call (e.g. a JSON-RPC batch), hence the slice return.
+	Parse(sessionKey string, isReq bool, data []byte) ([]ParsedEvent, error)
+}
+
+var registry []Parser
+
+// Register adds a Parser to the global registry. Parsers are tried in
+// registration order, so callers that want first refusal (e.g. a
+// narrowly-scoped protocol) should register during an early init().
+func Register(p Parser) {
+	registry = append(registry, p)
+}
+
+// Registered returns the parsers registered so far, in registration order.
+func Registered() []Parser {
+	out := make([]Parser, len(registry))
+	copy(out, registry)
+	return out
+}
+
+// Detect returns the first registered parser whose Detect method claims
+// payload, or nil if none do.
+func Detect(payload []byte, destPort uint16) Parser {
+	for _, p := range registry {
+		if p.Detect(payload, destPort) {
+			return p
+		}
+	}
+	return nil
+}
+
+// ErrNoParser is returned by callers that require a match when Detect
+// finds none.
+var ErrNoParser = fmt.Errorf("parsers: no parser matched payload")
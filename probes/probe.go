@@ -0,0 +1,259 @@
+// Package probes defines the Probe interface the agent uses to attach
+// independent eBPF instrumentation points and fan their perf events into
+// a single channel. Each concrete probe (tcp_sendmsg/recvmsg,
+// tcp_retransmit_skb, tcp_reset, socket_latency, ...) lives in its own
+// file and is self-contained: loading, attaching, decoding, and closing
+// its own eBPF objects. ProbeManager only knows the interface, so adding
+// a probe never touches the others.
+package probes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+)
+
+// Probe is one independent piece of eBPF instrumentation: a program
+// loaded into the kernel, attached at some hook, and exposing a single
+// perf event array that ProbeManager reads from.
+type Probe interface {
+	// Name identifies the probe for logging and ProbeManager, e.g.
+	// "tcp_send_recv", "tcp_retransmit", "socket_latency". It matches the
+	// probe's PROBES env var entry, except that "tcp_send" and "tcp_recv"
+	// both name the "tcp_send_recv" probe.
+	Name() string
+	// Load reads and verifies the probe's compiled eBPF objects. It must
+	// be called before Attach.
+	Load(ctx context.Context) error
+	// Attach attaches the loaded program to its kernel hook point and
+	// returns the resulting link, which the caller is responsible for
+	// closing (ProbeManager does this in Close).
+	Attach() (link.Link, error)
+	// Events returns the perf event array the probe publishes raw
+	// samples to.
+	Events() *ebpf.Map
+	// Decode converts one raw perf record from this probe into its
+	// typed event. The concrete type varies per probe; callers type
+	// switch on it.
+	Decode(record perf.Record) (any, error)
+	// Close releases the probe's eBPF objects. Safe to call even if
+	// Load was never called.
+	Close() error
+}
+
+// Event wraps a decoded probe event with the name of the probe that
+// produced it, so a single fan-in channel can carry events from every
+// attached probe.
+type Event struct {
+	Probe string
+	Data  any
+}
+
+// attached bundles the running state of one probe so it can be torn
+// down individually, e.g. by Disable.
+type attached struct {
+	probe  Probe
+	link   link.Link
+	reader *perf.Reader
+}
+
+// ProbeManager owns the lifecycle of a set of probes: loading, attaching,
+// running a perf reader per probe, and fanning every decoded event into
+// one channel for the agent to consume. Probes can be added up front via
+// Add/Start, or attached and detached afterwards via Enable/Disable
+// (e.g. from the control server), which is why the running set is
+// guarded by a mutex rather than built once at Start.
+type ProbeManager struct {
+	mu      sync.Mutex
+	running map[string]*attached
+	ctx     context.Context
+	events  chan Event
+}
+
+// NewProbeManager creates a ProbeManager with no probes registered yet.
+func NewProbeManager() *ProbeManager {
+	return &ProbeManager{
+		running: make(map[string]*attached),
+		events:  make(chan Event, 4096),
+	}
+}
+
+// Add registers a probe with the manager. It must be called before Start.
+func (m *ProbeManager) Add(p Probe) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running[p.Name()] = &attached{probe: p}
+}
+
+// Names returns the names of every probe currently attached.
+func (m *ProbeManager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Start loads and attaches every probe registered via Add and begins
+// fanning their perf events into Events(). If any probe fails to load or
+// attach, Start closes the probes it already started and returns the
+// error. The context is retained so Enable can attach further probes
+// later with the same lifetime.
+func (m *ProbeManager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	m.ctx = ctx
+	probesToStart := make([]Probe, 0, len(m.running))
+	for _, a := range m.running {
+		probesToStart = append(probesToStart, a.probe)
+	}
+	m.mu.Unlock()
+
+	for _, p := range probesToStart {
+		if err := m.attach(ctx, p); err != nil {
+			m.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// Enable attaches an additional probe at runtime. It requires Start to
+// have already been called, since it reuses Start's context for the
+// probe's read loop. Enabling a probe that's already running replaces it.
+func (m *ProbeManager) Enable(ctx context.Context, p Probe) error {
+	m.mu.Lock()
+	startCtx := m.ctx
+	m.mu.Unlock()
+	if startCtx == nil {
+		return fmt.Errorf("probes: manager not started")
+	}
+
+	if err := m.Disable(p.Name()); err != nil {
+		return err
+	}
+	return m.attach(startCtx, p)
+}
+
+// Disable detaches and closes a running probe by name. It is a no-op if
+// the probe isn't currently attached.
+func (m *ProbeManager) Disable(name string) error {
+	m.mu.Lock()
+	a, ok := m.running[name]
+	if ok {
+		delete(m.running, name)
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	var firstErr error
+	if a.reader != nil {
+		if err := a.reader.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if a.link != nil {
+		if err := a.link.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := a.probe.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+func (m *ProbeManager) attach(ctx context.Context, p Probe) error {
+	if err := p.Load(ctx); err != nil {
+		return fmt.Errorf("probes: loading %q: %w", p.Name(), err)
+	}
+
+	lnk, err := p.Attach()
+	if err != nil {
+		p.Close()
+		return fmt.Errorf("probes: attaching %q: %w", p.Name(), err)
+	}
+
+	rd, err := perf.NewReader(p.Events(), perfBufferSize)
+	if err != nil {
+		lnk.Close()
+		p.Close()
+		return fmt.Errorf("probes: opening perf reader for %q: %w", p.Name(), err)
+	}
+
+	m.mu.Lock()
+	m.running[p.Name()] = &attached{probe: p, link: lnk, reader: rd}
+	m.mu.Unlock()
+
+	go m.readLoop(ctx, p, rd)
+	return nil
+}
+
+// perfBufferSize matches the buffer size the single-probe agent used
+// historically (os.Getpagesize()*64, with the common 4KiB page size).
+const perfBufferSize = 4096 * 64
+
+func (m *ProbeManager) readLoop(ctx context.Context, p Probe, rd *perf.Reader) {
+	for {
+		record, err := rd.Read()
+		if err != nil {
+			return
+		}
+		if record.LostSamples > 0 {
+			m.events <- Event{Probe: p.Name(), Data: fmt.Errorf("lost %d samples", record.LostSamples)}
+			continue
+		}
+
+		decoded, err := p.Decode(record)
+		if err != nil {
+			m.events <- Event{Probe: p.Name(), Data: err}
+			continue
+		}
+
+		select {
+		case m.events <- Event{Probe: p.Name(), Data: decoded}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Events returns the channel every attached probe's decoded events are
+// fanned into.
+func (m *ProbeManager) Events() <-chan Event {
+	return m.events
+}
+
+// Close closes every attached probe's perf reader, link, and eBPF
+// objects, collecting and returning the first error encountered.
+func (m *ProbeManager) Close() error {
+	m.mu.Lock()
+	running := m.running
+	m.running = make(map[string]*attached)
+	m.mu.Unlock()
+
+	var firstErr error
+	for _, a := range running {
+		if a.reader != nil {
+			if err := a.reader.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if a.link != nil {
+			if err := a.link.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := a.probe.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
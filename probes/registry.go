@@ -0,0 +1,37 @@
+package probes
+
+import "fmt"
+
+// factories maps a probe's env-var name (as used in PROBES=...) to a
+// constructor for a fresh instance. Kept separate from the individual
+// probe files so adding a probe doesn't require touching this file's
+// neighbors, just appending one line here.
+var factories = map[string]func() Probe{
+	"tcp_send":       func() Probe { return &TCPSendRecvProbe{} },
+	"tcp_recv":       func() Probe { return &TCPSendRecvProbe{} },
+	"tcp_retransmit": func() Probe { return &TCPRetransmitProbe{} },
+	"tcp_reset":      func() Probe { return &TCPResetProbe{} },
+	"socket_latency": func() Probe { return &SocketLatencyProbe{} },
+}
+
+// New constructs a fresh Probe for the given PROBES env var entry.
+// "tcp_send" and "tcp_recv" both resolve to TCPSendRecvProbe, since one
+// pair of kprobes covers both directions; callers enabling both names
+// should only add the probe once (New is a constructor, not a
+// deduplicator).
+func New(name string) (Probe, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("probes: unknown probe %q", name)
+	}
+	return factory(), nil
+}
+
+// Available returns the set of probe names New accepts.
+func Available() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package probes
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -target bpfel socketlatency socket_latency.c -- -D__TARGET_ARCH_x86 -I/usr/include/x86_64-linux-gnu
+
+// LatencyEvent reports the time between a connection's tcp_connect and
+// its first tcp_sendmsg on the same 5-tuple. The subtraction happens in
+// the BPF program (keyed on an LRU map of in-flight connect timestamps),
+// so LatencyDeltaNs already holds the finished measurement. Must match
+// latency_event_t in socket_latency.c.
+type LatencyEvent struct {
+	PID            uint64
+	LatencyDeltaNs uint64
+	DestIP         uint32
+	DestPort       uint16
+	Comm           [16]byte
+}
+
+// SocketLatencyProbe attaches kprobes to tcp_connect and tcp_sendmsg and
+// measures, per 5-tuple, the time from connection establishment to the
+// first byte written — the connection-setup portion of RPC latency that
+// request/response correlation alone can't see.
+type SocketLatencyProbe struct {
+	objs      socketlatencyObjects
+	kpConnect link.Link
+}
+
+// Name implements Probe.
+func (p *SocketLatencyProbe) Name() string { return "socket_latency" }
+
+// Load implements Probe.
+func (p *SocketLatencyProbe) Load(ctx context.Context) error {
+	return loadSocketlatencyObjects(&p.objs, nil)
+}
+
+// Attach implements Probe.
+func (p *SocketLatencyProbe) Attach() (link.Link, error) {
+	kpConnect, err := link.Kprobe("tcp_connect", p.objs.TraceTcpConnect, nil)
+	if err != nil {
+		return nil, fmt.Errorf("attaching tcp_connect kprobe: %w", err)
+	}
+	p.kpConnect = kpConnect
+
+	kpSend, err := link.Kprobe("tcp_sendmsg", p.objs.TraceTcpSendmsgLatency, nil)
+	if err != nil {
+		kpConnect.Close()
+		return nil, fmt.Errorf("attaching tcp_sendmsg kprobe: %w", err)
+	}
+	return kpSend, nil
+}
+
+// Events implements Probe.
+func (p *SocketLatencyProbe) Events() *ebpf.Map { return p.objs.Events }
+
+// Decode implements Probe.
+func (p *SocketLatencyProbe) Decode(record perf.Record) (any, error) {
+	var event LatencyEvent
+	if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.LittleEndian, &event); err != nil {
+		return nil, fmt.Errorf("decoding socket_latency event: %w", err)
+	}
+	return event, nil
+}
+
+// Close implements Probe.
+func (p *SocketLatencyProbe) Close() error {
+	if p.kpConnect != nil {
+		p.kpConnect.Close()
+	}
+	return p.objs.Close()
+}
@@ -0,0 +1,64 @@
+package probes
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -target bpfel tcpreset tcp_reset.c -- -D__TARGET_ARCH_x86 -I/usr/include/x86_64-linux-gnu
+
+// ResetEvent is emitted when the kernel sends a TCP RST on a traced
+// connection. Must match reset_event_t in tcp_reset.c.
+type ResetEvent struct {
+	PID      uint64
+	DestIP   uint32
+	DestPort uint16
+	Comm     [16]byte
+}
+
+// TCPResetProbe attaches a kprobe to tcp_v4_send_reset and counts
+// connection resets per destination, surfacing backend-initiated
+// disconnects that would otherwise only show up as client-side errors.
+type TCPResetProbe struct {
+	objs tcpresetObjects
+}
+
+// Name implements Probe.
+func (p *TCPResetProbe) Name() string { return "tcp_reset" }
+
+// Load implements Probe.
+func (p *TCPResetProbe) Load(ctx context.Context) error {
+	return loadTcpresetObjects(&p.objs, nil)
+}
+
+// Attach implements Probe.
+func (p *TCPResetProbe) Attach() (link.Link, error) {
+	kp, err := link.Kprobe("tcp_v4_send_reset", p.objs.TraceTcpV4SendReset, nil)
+	if err != nil {
+		return nil, fmt.Errorf("attaching tcp_v4_send_reset kprobe: %w", err)
+	}
+	return kp, nil
+}
+
+// Events implements Probe.
+func (p *TCPResetProbe) Events() *ebpf.Map { return p.objs.Events }
+
+// Decode implements Probe.
+func (p *TCPResetProbe) Decode(record perf.Record) (any, error) {
+	var event ResetEvent
+	if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.LittleEndian, &event); err != nil {
+		return nil, fmt.Errorf("decoding tcp_reset event: %w", err)
+	}
+	return event, nil
+}
+
+// Close implements Probe.
+func (p *TCPResetProbe) Close() error {
+	return p.objs.Close()
+}
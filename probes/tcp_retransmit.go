@@ -0,0 +1,66 @@
+package probes
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -target bpfel tcpretransmit tcp_retransmit.c -- -D__TARGET_ARCH_x86 -I/usr/include/x86_64-linux-gnu
+
+// RetransmitEvent is emitted whenever the kernel retransmits a segment
+// on a traced connection. Must match retransmit_event_t in
+// tcp_retransmit.c.
+type RetransmitEvent struct {
+	PID      uint64
+	DestIP   uint32
+	DestPort uint16
+	Comm     [16]byte
+}
+
+// TCPRetransmitProbe attaches a kprobe to tcp_retransmit_skb and
+// publishes a `retransmit` feature per segment retransmitted, giving
+// operators a direct signal of path congestion or packet loss to an RPC
+// backend.
+type TCPRetransmitProbe struct {
+	objs tcpretransmitObjects
+}
+
+// Name implements Probe.
+func (p *TCPRetransmitProbe) Name() string { return "tcp_retransmit" }
+
+// Load implements Probe.
+func (p *TCPRetransmitProbe) Load(ctx context.Context) error {
+	return loadTcpretransmitObjects(&p.objs, nil)
+}
+
+// Attach implements Probe.
+func (p *TCPRetransmitProbe) Attach() (link.Link, error) {
+	kp, err := link.Kprobe("tcp_retransmit_skb", p.objs.TraceTcpRetransmitSkb, nil)
+	if err != nil {
+		return nil, fmt.Errorf("attaching tcp_retransmit_skb kprobe: %w", err)
+	}
+	return kp, nil
+}
+
+// Events implements Probe.
+func (p *TCPRetransmitProbe) Events() *ebpf.Map { return p.objs.Events }
+
+// Decode implements Probe.
+func (p *TCPRetransmitProbe) Decode(record perf.Record) (any, error) {
+	var event RetransmitEvent
+	if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.LittleEndian, &event); err != nil {
+		return nil, fmt.Errorf("decoding tcp_retransmit event: %w", err)
+	}
+	return event, nil
+}
+
+// Close implements Probe.
+func (p *TCPRetransmitProbe) Close() error {
+	return p.objs.Close()
+}
@@ -0,0 +1,87 @@
+package probes
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -target bpfel tcpsendrecv tcp_send_recv.c -- -D__TARGET_ARCH_x86 -I/usr/include/x86_64-linux-gnu
+
+// TCPEvent is the per-direction event the tcp_sendmsg/tcp_recvmsg probe
+// emits. It is the historical RPCEvent shape from the single-probe
+// agent, kept byte-for-byte compatible with the C struct (network_event_t)
+// in tcp_send_recv.c.
+type TCPEvent struct {
+	PID         uint64
+	TimestampNs uint64
+	DataLen     uint32
+	IsSend      uint32 // 1 = send (tcp_sendmsg), 0 = recv (tcp_recvmsg)
+	DestIP      uint32
+	DestPort    uint16
+	Comm        [16]byte
+	Data        [512]byte
+}
+
+// TCPSendRecvProbe attaches kprobes to both tcp_sendmsg and tcp_recvmsg,
+// so request and response traffic on a connection are both captured
+// (tcp_recvmsg was previously unwired, which meant response sizes and
+// latencies were invisible).
+type TCPSendRecvProbe struct {
+	objs   tcpsendrecvObjects
+	kpSend link.Link
+	kpRecv link.Link
+}
+
+// Name implements Probe.
+func (p *TCPSendRecvProbe) Name() string { return "tcp_send_recv" }
+
+// Load implements Probe.
+func (p *TCPSendRecvProbe) Load(ctx context.Context) error {
+	return loadTcpsendrecvObjects(&p.objs, nil)
+}
+
+// Attach implements Probe. It attaches both kprobes and returns the
+// tcp_sendmsg link as the primary one; the tcp_recvmsg link is tracked
+// internally and released in Close.
+func (p *TCPSendRecvProbe) Attach() (link.Link, error) {
+	kpSend, err := link.Kprobe("tcp_sendmsg", p.objs.TraceTcpSendmsg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("attaching tcp_sendmsg kprobe: %w", err)
+	}
+	p.kpSend = kpSend
+
+	kpRecv, err := link.Kprobe("tcp_recvmsg", p.objs.TraceTcpRecvmsg, nil)
+	if err != nil {
+		kpSend.Close()
+		return nil, fmt.Errorf("attaching tcp_recvmsg kprobe: %w", err)
+	}
+	p.kpRecv = kpRecv
+
+	return kpSend, nil
+}
+
+// Events implements Probe.
+func (p *TCPSendRecvProbe) Events() *ebpf.Map { return p.objs.Events }
+
+// Decode implements Probe.
+func (p *TCPSendRecvProbe) Decode(record perf.Record) (any, error) {
+	var event TCPEvent
+	if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.LittleEndian, &event); err != nil {
+		return nil, fmt.Errorf("decoding tcp_send_recv event: %w", err)
+	}
+	return event, nil
+}
+
+// Close implements Probe.
+func (p *TCPSendRecvProbe) Close() error {
+	if p.kpRecv != nil {
+		p.kpRecv.Close()
+	}
+	return p.objs.Close()
+}
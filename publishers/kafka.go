@@ -0,0 +1,59 @@
+package publishers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher publishes features to Kafka. The NATS subject becomes
+// the topic, and the message key is set to the destination hostname
+// segment of the subject (rpc.{dest_hostname}.{protocol}.{method}.{metric})
+// so all features for one upstream land on the same partition.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a publisher that writes to any of brokers,
+// picking the topic per message (AllowAutoTopicCreation is on, matching
+// how NATS subjects are created implicitly today).
+func NewKafkaPublisher(brokers []string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+// Publish implements Publisher. contentType is carried as a header so
+// consumers can distinguish senml+cbor from plain JSON bodies.
+func (p *KafkaPublisher) Publish(subject string, payload []byte, contentType string) error {
+	msg := kafka.Message{
+		Topic: subject,
+		Key:   []byte(destHostnameFromSubject(subject)),
+		Value: payload,
+	}
+	if contentType != "" {
+		msg.Headers = []kafka.Header{{Key: "Content-Type", Value: []byte(contentType)}}
+	}
+	return p.writer.WriteMessages(context.Background(), msg)
+}
+
+// Close implements Publisher.
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// destHostnameFromSubject pulls the {dest_hostname} segment out of an
+// rpc.{dest_hostname}.{protocol}.{method}.{metric} subject, falling back
+// to the full subject if it doesn't match that shape.
+func destHostnameFromSubject(subject string) string {
+	parts := strings.SplitN(subject, ".", 3)
+	if len(parts) >= 2 {
+		return parts[1]
+	}
+	return subject
+}
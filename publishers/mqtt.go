@@ -0,0 +1,40 @@
+package publishers
+
+import (
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTTPublisher publishes features to an MQTT broker. The NATS subject
+// is used as-is as the MQTT topic.
+type MQTTPublisher struct {
+	client mqtt.Client
+	qos    byte
+}
+
+// NewMQTTPublisher connects to broker (e.g. "tcp://localhost:1883") with
+// the given client id and QoS level.
+func NewMQTTPublisher(broker, clientID string, qos byte) (*MQTTPublisher, error) {
+	opts := mqtt.NewClientOptions().AddBroker(broker).SetClientID(clientID)
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("mqtt: connecting to %s: %w", broker, token.Error())
+	}
+	return &MQTTPublisher{client: client, qos: qos}, nil
+}
+
+// Publish implements Publisher. The paho v3 client has no per-message
+// property support (that's MQTT v5), so contentType is accepted to
+// satisfy the interface but otherwise unused.
+func (p *MQTTPublisher) Publish(subject string, payload []byte, contentType string) error {
+	token := p.client.Publish(subject, p.qos, false, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// Close implements Publisher.
+func (p *MQTTPublisher) Close() error {
+	p.client.Disconnect(250)
+	return nil
+}
@@ -0,0 +1,45 @@
+package publishers
+
+import (
+	"errors"
+	"sync"
+)
+
+// MultiPublisher fans a single Publish call out to several backends
+// concurrently, so e.g. mirroring features to Kafka for long-term
+// storage doesn't add NATS's publish latency (or vice versa). A failure
+// in one backend is isolated: every backend is always attempted, and the
+// errors (if any) are joined together rather than the first one winning.
+type MultiPublisher struct {
+	backends []Publisher
+}
+
+// NewMultiPublisher creates a MultiPublisher that fans out to backends.
+func NewMultiPublisher(backends ...Publisher) *MultiPublisher {
+	return &MultiPublisher{backends: backends}
+}
+
+// Publish implements Publisher.
+func (m *MultiPublisher) Publish(subject string, payload []byte, contentType string) error {
+	errs := make([]error, len(m.backends))
+	var wg sync.WaitGroup
+	for i, backend := range m.backends {
+		wg.Add(1)
+		go func(i int, backend Publisher) {
+			defer wg.Done()
+			errs[i] = backend.Publish(subject, payload, contentType)
+		}(i, backend)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Close implements Publisher, closing every backend and joining any
+// errors encountered.
+func (m *MultiPublisher) Close() error {
+	errs := make([]error, len(m.backends))
+	for i, backend := range m.backends {
+		errs[i] = backend.Close()
+	}
+	return errors.Join(errs...)
+}
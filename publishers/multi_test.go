@@ -0,0 +1,149 @@
+package publishers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// memoryPublisher is an in-memory Publisher used only by tests: it
+// records every Publish call instead of sending anywhere, and can be
+// told to fail to exercise MultiPublisher's error handling.
+type memoryPublisher struct {
+	mu        sync.Mutex
+	published []publishedMessage
+	closed    bool
+	failWith  error
+}
+
+type publishedMessage struct {
+	subject     string
+	payload     string
+	contentType string
+}
+
+func (m *memoryPublisher) Publish(subject string, payload []byte, contentType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, publishedMessage{subject, string(payload), contentType})
+	return m.failWith
+}
+
+func (m *memoryPublisher) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+func (m *memoryPublisher) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.published)
+}
+
+func TestMultiPublisherFansOutToEveryBackend(t *testing.T) {
+	a := &memoryPublisher{}
+	b := &memoryPublisher{}
+	mp := NewMultiPublisher(a, b)
+
+	if err := mp.Publish("rpc.geth-0.jsonrpc2.eth_call.latency_ms", []byte(`{"v":1}`), "application/json"); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	for _, backend := range []*memoryPublisher{a, b} {
+		if backend.count() != 1 {
+			t.Errorf("backend received %d messages, want 1", backend.count())
+		}
+	}
+	if a.published[0] != b.published[0] {
+		t.Errorf("backends received different messages: %+v vs %+v", a.published[0], b.published[0])
+	}
+}
+
+func TestMultiPublisherConcurrentFanOut(t *testing.T) {
+	backends := make([]*memoryPublisher, 5)
+	publishers := make([]Publisher, 5)
+	for i := range backends {
+		backends[i] = &memoryPublisher{}
+		publishers[i] = backends[i]
+	}
+	mp := NewMultiPublisher(publishers...)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			subject := fmt.Sprintf("rpc.geth-0.jsonrpc2.eth_call.latency_ms.%d", i)
+			if err := mp.Publish(subject, []byte("{}"), "application/json"); err != nil {
+				t.Errorf("Publish: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, backend := range backends {
+		if backend.count() != n {
+			t.Errorf("backend received %d messages, want %d", backend.count(), n)
+		}
+	}
+}
+
+func TestMultiPublisherJoinsErrorsButStillPublishesToEveryBackend(t *testing.T) {
+	errA := errors.New("backend a: connection reset")
+	a := &memoryPublisher{failWith: errA}
+	b := &memoryPublisher{}
+	mp := NewMultiPublisher(a, b)
+
+	err := mp.Publish("rpc.geth-0.jsonrpc2.eth_call.latency_ms", []byte("{}"), "application/json")
+	if err == nil {
+		t.Fatal("Publish() error = nil, want the failing backend's error")
+	}
+	if !errors.Is(err, errA) {
+		t.Errorf("Publish() error = %v, want it to wrap %v", err, errA)
+	}
+
+	// The failing backend still received the message, and so did the
+	// healthy one: a failure in one backend must not short-circuit the
+	// others.
+	if a.count() != 1 {
+		t.Errorf("failing backend received %d messages, want 1", a.count())
+	}
+	if b.count() != 1 {
+		t.Errorf("healthy backend received %d messages, want 1", b.count())
+	}
+}
+
+func TestMultiPublisherCloseClosesEveryBackendAndJoinsErrors(t *testing.T) {
+	a := &memoryPublisher{}
+	errB := errors.New("backend b: already closed")
+	b := &closeErrorPublisher{memoryPublisher: &memoryPublisher{}, err: errB}
+	mp := NewMultiPublisher(a, b)
+
+	err := mp.Close()
+	if !errors.Is(err, errB) {
+		t.Errorf("Close() error = %v, want it to wrap %v", err, errB)
+	}
+	if !a.closed {
+		t.Error("healthy backend was not closed")
+	}
+	if !b.memoryPublisher.closed {
+		t.Error("failing backend's underlying Close was not invoked")
+	}
+}
+
+// closeErrorPublisher wraps a memoryPublisher to fail Close without
+// affecting Publish, so MultiPublisher.Close's error-joining can be
+// exercised independently of Publish's.
+type closeErrorPublisher struct {
+	*memoryPublisher
+	err error
+}
+
+func (c *closeErrorPublisher) Close() error {
+	_ = c.memoryPublisher.Close()
+	return c.err
+}
@@ -0,0 +1,52 @@
+package publishers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher is the agent's original (and default) backend.
+type NATSPublisher struct {
+	conn *nats.Conn
+}
+
+// NewNATSPublisher connects to a NATS server at url, retrying with
+// exponential backoff as the agent did before the Publisher abstraction
+// existed.
+func NewNATSPublisher(url string) (*NATSPublisher, error) {
+	log.Printf("Connecting to NATS at %s...", url)
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		nc, err := nats.Connect(url, nats.Timeout(5*time.Second))
+		if err == nil {
+			log.Println("Successfully connected to NATS.")
+			return &NATSPublisher{conn: nc}, nil
+		}
+		lastErr = err
+		log.Printf("NATS connection attempt %d failed: %v. Retrying in %d seconds...", i+1, err, 1<<i)
+		time.Sleep(time.Duration(1<<i) * time.Second)
+	}
+	return nil, fmt.Errorf("failed to connect to NATS after multiple retries: %w", lastErr)
+}
+
+// Publish implements Publisher. When contentType is set, it publishes a
+// nats.Msg with a Content-Type header instead of a bare payload, so
+// consumers can tell a senml+cbor body from plain JSON.
+func (p *NATSPublisher) Publish(subject string, payload []byte, contentType string) error {
+	if contentType == "" {
+		return p.conn.Publish(subject, payload)
+	}
+	msg := nats.NewMsg(subject)
+	msg.Data = payload
+	msg.Header.Set("Content-Type", contentType)
+	return p.conn.PublishMsg(msg)
+}
+
+// Close implements Publisher.
+func (p *NATSPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
@@ -0,0 +1,19 @@
+// Package publishers abstracts the agent's outbound transport so feature
+// events aren't hard-wired to NATS. Each backend (NATS, MQTT, Kafka,
+// stdout, ...) implements Publisher in its own file; MultiPublisher
+// composes several so operators can mirror a stream to more than one
+// backend at once.
+package publishers
+
+// Publisher sends a feature payload under subject to some external
+// system. subject follows the hierarchical
+// rpc.{destination}.{protocol}.{method}.{metric} naming the agent already
+// uses; backends that use "topic" rather than "subject" terminology
+// (MQTT, Kafka) just treat it as one. contentType reflects the Encoder
+// that produced payload (e.g. "application/json", "application/senml+cbor");
+// backends that support per-message metadata should set it, others may
+// ignore it.
+type Publisher interface {
+	Publish(subject string, payload []byte, contentType string) error
+	Close() error
+}
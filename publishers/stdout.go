@@ -0,0 +1,32 @@
+package publishers
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// StdoutPublisher writes features to stdout, one line per publish. It's
+// useful for local development and CI where running a message broker
+// alongside the agent isn't worth the setup.
+type StdoutPublisher struct {
+	mu sync.Mutex
+}
+
+// NewStdoutPublisher creates a StdoutPublisher.
+func NewStdoutPublisher() *StdoutPublisher {
+	return &StdoutPublisher{}
+}
+
+// Publish implements Publisher.
+func (p *StdoutPublisher) Publish(subject string, payload []byte, contentType string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := fmt.Fprintf(os.Stdout, "[%s] (%s) %s\n", subject, contentType, payload)
+	return err
+}
+
+// Close implements Publisher.
+func (p *StdoutPublisher) Close() error {
+	return nil
+}